@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// CertificateWarningDaysFor returns the number of days before the expiry of a
+// certificate the supplied path is reported as limited.
+func CertificateWarningDaysFor(path string) int {
+	if days := config.Default.PathSettings(path).CertificateWarningDays; days != nil {
+		return *days
+	}
+	return config.Default.Viper().GetInt(config.ConfigurationKey_CertificatesWarningDays)
+}
+
+// watchCertificates passes the hosts of all tls routers to the probe runner.
+func (e *Engine) watchCertificates(statuses []v1.ServiceStatus) {
+	var hosts []string
+	for _, status := range statuses {
+		for _, certificate := range status.Certificates {
+			hosts = append(hosts, certificate.Host)
+		}
+	}
+	e.probes.WatchCertificates(hosts)
+}
+
+// applyCertificates completes the certificates of the status with the latest
+// checks and degrades the status if a certificate expires soon, has expired,
+// does not cover its host or could not be read.
+// Certificates which have not been checked yet are omitted.
+func (e *Engine) applyCertificates(status v1.ServiceStatus) v1.ServiceStatus {
+	if len(status.Certificates) == 0 {
+		return status
+	}
+
+	warningDays := CertificateWarningDaysFor(status.Path)
+	certificates := make([]v1.CertificateStatus, 0, len(status.Certificates))
+	for _, pending := range status.Certificates {
+		certificate, ok := e.probes.Certificate(pending.Host)
+		if !ok {
+			continue
+		}
+		certificates = append(certificates, certificate)
+
+		candidate, reason, message := certificateDegradation(certificate, warningDays)
+		if candidate == "" {
+			continue
+		}
+		if worse := v1.WorseStatus(status.Status, candidate); worse != status.Status {
+			status.Status = worse
+			status.Reason = reason
+			status.Message = message
+		}
+	}
+
+	status.Certificates = certificates
+	return status
+}
+
+// certificateDegradation returns the status a certificate degrades its path
+// to together with the reason and message. If the certificate does not
+// degrade the path, the returned status is empty.
+// Certificates which could not be read (e.g. since the connection failed)
+// limit the path, as clients may be unable to connect as well.
+func certificateDegradation(certificate v1.CertificateStatus, warningDays int) (status, reason, message string) {
+	switch {
+	case certificate.NotAfter == nil:
+		return v1.ServiceStatusIssues, v1.ReasonCertificateUnavailable,
+			fmt.Sprintf("certificate of %s could not be checked: %s", certificate.Host, certificate.Error)
+	case certificate.DaysUntilExpiry < 0:
+		return v1.ServiceStatusDown, v1.ReasonCertificateExpired,
+			fmt.Sprintf("certificate of %s expired on %s", certificate.Host, certificate.NotAfter.Format("2006-01-02"))
+	case !certificate.Covered:
+		return v1.ServiceStatusIssues, v1.ReasonCertificateMismatch,
+			fmt.Sprintf("certificate presented for %s does not cover the host", certificate.Host)
+	case certificate.DaysUntilExpiry < warningDays:
+		return v1.ServiceStatusIssues, v1.ReasonCertificateExpiring,
+			fmt.Sprintf("certificate of %s expires in %d days", certificate.Host, certificate.DaysUntilExpiry)
+	default:
+		return "", "", ""
+	}
+}
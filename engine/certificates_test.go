@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+func TestCertificateDegradation(t *testing.T) {
+	notAfter := time.Now().Add(30 * 24 * time.Hour)
+	valid := v1.CertificateStatus{Host: "example.com", NotAfter: &notAfter, DaysUntilExpiry: 30, Covered: true}
+
+	expiring, expired, mismatch := valid, valid, valid
+	expiring.DaysUntilExpiry = 3
+	expired.DaysUntilExpiry = -1
+	mismatch.Covered = false
+
+	tests := []struct {
+		name        string
+		certificate v1.CertificateStatus
+		wantStatus  string
+		wantReason  string
+	}{
+		{"valid", valid, "", ""},
+		{"expiring", expiring, v1.ServiceStatusIssues, v1.ReasonCertificateExpiring},
+		{"expired", expired, v1.ServiceStatusDown, v1.ReasonCertificateExpired},
+		{"not covering the host", mismatch, v1.ServiceStatusIssues, v1.ReasonCertificateMismatch},
+		{
+			name:        "unreadable",
+			certificate: v1.CertificateStatus{Host: "example.com", Error: "connection refused"},
+			wantStatus:  v1.ServiceStatusIssues,
+			wantReason:  v1.ReasonCertificateUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reason, _ := certificateDegradation(tt.certificate, 14)
+			if status != tt.wantStatus || reason != tt.wantReason {
+				t.Errorf("certificateDegradation() = %q (%q), want %q (%q)", status, reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// dampedStatuses are the statuses derived from the upstream health checks.
+// Only transitions between them are damped, all other statuses are caused by
+// the configuration and reported immediately.
+var dampedStatuses = []string{v1.ServiceStatusOk, v1.ServiceStatusIssues, v1.ServiceStatusDown}
+
+// Damping describes how transitions between statuses of a path are damped.
+type Damping struct {
+	FailureThreshold int           // consecutive failing observations before reporting a failure
+	SuccessThreshold int           // consecutive successful observations before reporting "ok"
+	FlapThreshold    int           // status changes within the window marking the path as flapping, zero disables it
+	FlapWindow       time.Duration // window in which the status changes are counted
+}
+
+// DampingFor returns the damping configured for the supplied path.
+// Settings not overridden for the path are taken from the global
+// configuration.
+func DampingFor(path string) Damping {
+	c := config.Default.Viper()
+	d := Damping{
+		FailureThreshold: c.GetInt(config.ConfigurationKey_StatusFailureThreshold),
+		SuccessThreshold: c.GetInt(config.ConfigurationKey_StatusSuccessThreshold),
+		FlapThreshold:    c.GetInt(config.ConfigurationKey_StatusFlapThreshold),
+		FlapWindow:       c.GetDuration(config.ConfigurationKey_StatusFlapWindow),
+	}
+
+	settings := config.Default.PathSettings(path)
+	if settings.FailureThreshold != nil {
+		d.FailureThreshold = *settings.FailureThreshold
+	}
+	if settings.SuccessThreshold != nil {
+		d.SuccessThreshold = *settings.SuccessThreshold
+	}
+	if settings.FlapThreshold != nil {
+		d.FlapThreshold = *settings.FlapThreshold
+	}
+	if settings.FlapWindow != nil {
+		d.FlapWindow = *settings.FlapWindow
+	}
+
+	return d
+}
+
+// dampingState remembers the observations of a single path.
+type dampingState struct {
+	reported    string
+	observed    string
+	failures    int
+	successes   int
+	transitions []time.Time
+}
+
+// damp applies the damping configured for the path to the observed status.
+// The returned bool reports if the observed status differs from the reported
+// status and awaits further confirmation.
+// The caller needs to hold the cache lock.
+func (e *Engine) damp(status v1.ServiceStatus, now time.Time) (v1.ServiceStatus, bool) {
+	state, ok := e.damping[status.Path]
+	if !ok || !slices.Contains(dampedStatuses, status.Status) || !slices.Contains(dampedStatuses, state.reported) {
+		// the first observation and configuration caused statuses are
+		// reported as they are
+		e.damping[status.Path] = &dampingState{reported: status.Status, observed: status.Status}
+		return status, false
+	}
+
+	damping := DampingFor(status.Path)
+
+	if status.Status != state.observed {
+		state.transitions = append(state.transitions, now)
+		state.observed = status.Status
+	}
+	state.transitions = slices.DeleteFunc(state.transitions, func(t time.Time) bool {
+		return now.Sub(t) > damping.FlapWindow
+	})
+
+	if status.Status == v1.ServiceStatusOk {
+		state.failures = 0
+		state.successes++
+		if state.successes >= damping.SuccessThreshold {
+			state.reported = status.Status
+		}
+	} else {
+		state.successes = 0
+		state.failures++
+		if state.failures >= damping.FailureThreshold {
+			state.reported = status.Status
+		}
+	}
+
+	pending := state.reported != status.Status
+	if pending {
+		status.Message = fmt.Sprintf("observed %s, awaiting confirmation before reporting it", status.Status)
+		status.Status = state.reported
+		status.Reason = v1.ReasonTransitionPending
+	}
+
+	if damping.FlapThreshold > 0 && len(state.transitions) > damping.FlapThreshold {
+		status.Status = v1.ServiceStatusFlapping
+		status.Reason = v1.ReasonFlapping
+		status.Message = fmt.Sprintf("status changed %d times within %s", len(state.transitions), damping.FlapWindow)
+	}
+
+	return status, pending
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// setDamping configures the global damping for the duration of the test.
+func setDamping(t *testing.T, d Damping) {
+	t.Helper()
+
+	c := config.Default.Viper()
+	values := map[string]any{
+		config.ConfigurationKey_StatusFailureThreshold: d.FailureThreshold,
+		config.ConfigurationKey_StatusSuccessThreshold: d.SuccessThreshold,
+		config.ConfigurationKey_StatusFlapThreshold:    d.FlapThreshold,
+		config.ConfigurationKey_StatusFlapWindow:       d.FlapWindow,
+	}
+	for key, value := range values {
+		previous := c.Get(key)
+		c.Set(key, value)
+		t.Cleanup(func() { c.Set(key, previous) })
+	}
+}
+
+type observation struct {
+	observed    string
+	wantStatus  string
+	wantPending bool
+}
+
+func runDamping(t *testing.T, observations []observation, interval time.Duration) {
+	t.Helper()
+
+	e := &Engine{damping: make(map[string]*dampingState)}
+	now := time.Now()
+	for idx, o := range observations {
+		status, pending := e.damp(v1.ServiceStatus{Path: "/api", Status: o.observed}, now)
+		if status.Status != o.wantStatus || pending != o.wantPending {
+			t.Errorf("observation %d (%s) = %s, pending %t, want %s, pending %t",
+				idx, o.observed, status.Status, pending, o.wantStatus, o.wantPending)
+		}
+		now = now.Add(interval)
+	}
+}
+
+func TestDampThresholds(t *testing.T) {
+	setDamping(t, Damping{FailureThreshold: 3, SuccessThreshold: 2, FlapWindow: time.Minute})
+
+	runDamping(t, []observation{
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusOk, true},
+		{v1.ServiceStatusDown, v1.ServiceStatusOk, true},
+		{v1.ServiceStatusDown, v1.ServiceStatusDown, false},
+		{v1.ServiceStatusOk, v1.ServiceStatusDown, true},
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		// a single success resets the failures
+		{v1.ServiceStatusIssues, v1.ServiceStatusOk, true},
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusIssues, v1.ServiceStatusOk, true},
+	}, time.Second)
+}
+
+func TestDampWithoutThresholds(t *testing.T) {
+	setDamping(t, Damping{FailureThreshold: 1, SuccessThreshold: 1, FlapWindow: time.Minute})
+
+	runDamping(t, []observation{
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusDown, false},
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+	}, time.Second)
+}
+
+func TestDampConfigurationStatuses(t *testing.T) {
+	setDamping(t, Damping{FailureThreshold: 3, SuccessThreshold: 3, FlapWindow: time.Minute})
+
+	// statuses caused by the configuration are reported immediately
+	runDamping(t, []observation{
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusMisconfigured, v1.ServiceStatusMisconfigured, false},
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusNotFound, v1.ServiceStatusNotFound, false},
+	}, time.Second)
+}
+
+func TestDampFlapping(t *testing.T) {
+	setDamping(t, Damping{FailureThreshold: 1, SuccessThreshold: 1, FlapThreshold: 2, FlapWindow: time.Minute})
+
+	runDamping(t, []observation{
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusDown, false},
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusFlapping, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusFlapping, false},
+	}, time.Second)
+
+	// transitions outside the window are not counted
+	runDamping(t, []observation{
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusDown, false},
+		{v1.ServiceStatusOk, v1.ServiceStatusOk, false},
+		{v1.ServiceStatusDown, v1.ServiceStatusDown, false},
+	}, time.Minute)
+}
@@ -0,0 +1,423 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sosodev/duration"
+
+	config "microservice/internal/configuration"
+	"microservice/probe"
+	"microservice/traefik"
+	v1 "microservice/types/v1"
+)
+
+// DefaultInterval is used for subscribers which did not request a specific
+// update interval.
+const DefaultInterval = 15 * time.Second
+
+// minUpdateInterval is the shortest interval between two updates of a path,
+// even if the minimal poll interval is configured to be shorter.
+const minUpdateInterval = time.Second
+
+// idleWait determines how long the engine sleeps if no subscriber is attached.
+const idleWait = time.Minute
+
+// Default is the status engine shared by all websocket connections.
+// It is set up during the startup of the service.
+var Default *Engine
+
+// Engine polls the Traefik API once for the union of all subscribed paths and
+// fans the results out to the subscribers attached to its hub.
+// The latest status of every path is cached, allowing new subscribers to be
+// served without querying the Traefik API again.
+type Engine struct {
+	hub    *Hub
+	client *traefik.Client
+	probes *probe.Runner
+
+	cacheLock sync.RWMutex
+	cache     map[string]v1.ServiceStatus
+	lastPoll  time.Time
+
+	// upstreams tracks the state of every observed upstream to report since
+	// when the upstream is in its current state
+	upstreams map[string]upstreamState
+
+	// damping tracks the observed statuses of every path to damp the
+	// transitions between them
+	damping map[string]*dampingState
+
+	// the latest latency samples of the traefik api, the probes of every path
+	// and the tcp connects to every upstream
+	gatewayLatency  *latencyWindow
+	probeLatency    map[string]*latencyWindow
+	upstreamLatency map[string]*latencyWindow
+
+	pollLock   sync.Mutex
+	pollCancel context.CancelFunc
+
+	wakeup chan struct{}
+}
+
+// New creates a new engine using the supplied client to access the Traefik API.
+// The results of the probe runner are combined with the statuses derived from
+// Traefik.
+// The engine needs to be started using [Engine.Run] before it delivers any
+// updates to its subscribers.
+func New(client *traefik.Client, probes *probe.Runner) *Engine {
+	return &Engine{
+		hub:       newHub(),
+		client:    client,
+		probes:    probes,
+		cache:     make(map[string]v1.ServiceStatus),
+		upstreams: make(map[string]upstreamState),
+		damping:   make(map[string]*dampingState),
+
+		gatewayLatency:  &latencyWindow{},
+		probeLatency:    make(map[string]*latencyWindow),
+		upstreamLatency: make(map[string]*latencyWindow),
+		wakeup:          make(chan struct{}, 1),
+	}
+}
+
+// Hub returns the hub managing the subscribers of the engine.
+func (e *Engine) Hub() *Hub {
+	return e.hub
+}
+
+// Subscribe attaches the subscriber to the engine and adds the paths to the
+// paths it is subscribed to.
+// The subscriber receives a first update of the paths as soon as possible.
+// Intervals shorter than the minimal poll interval are raised to it, since
+// the statuses do not change more often.
+func (e *Engine) Subscribe(s *Subscriber, paths []string, options Options) {
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	minInterval := config.Default.Viper().GetDuration(config.ConfigurationKey_MonitorMinPollInterval)
+	options.Interval = max(options.Interval, minInterval, minUpdateInterval)
+	s.add(paths, options)
+	e.hub.add(s)
+	e.notify()
+}
+
+// Replace attaches the subscriber to the engine and replaces the paths it is
+// subscribed to.
+func (e *Engine) Replace(s *Subscriber, paths []string, options Options) {
+	s.remove()
+	e.Subscribe(s, paths, options)
+}
+
+// Unsubscribe removes the paths from the paths the subscriber is subscribed
+// to. If no paths are supplied or no paths are left, the subscriber is
+// detached from the engine.
+// If no subscribers are left, a currently running poll is canceled.
+func (e *Engine) Unsubscribe(s *Subscriber, paths ...string) {
+	if !s.remove(paths...) {
+		e.hub.remove(s)
+	}
+
+	if len(e.hub.Paths()) == 0 {
+		e.pollLock.Lock()
+		if e.pollCancel != nil {
+			e.pollCancel()
+		}
+		e.pollLock.Unlock()
+	}
+}
+
+// Status returns the cached status of the supplied path.
+func (e *Engine) Status(path string) (v1.ServiceStatus, bool) {
+	e.cacheLock.RLock()
+	defer e.cacheLock.RUnlock()
+	status, ok := e.cache[path]
+	return status, ok
+}
+
+func (e *Engine) notify() {
+	select {
+	case e.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Run executes the polling loop of the engine until the context is canceled.
+func (e *Engine) Run(ctx context.Context) {
+	timer := time.NewTimer(idleWait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-e.wakeup:
+		}
+
+		e.tick(ctx, time.Now())
+
+		wait := idleWait
+		if next, ok := e.hub.nextDue(); ok {
+			wait = max(time.Until(next), 0)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+func (e *Engine) tick(ctx context.Context, now time.Time) {
+	due := e.hub.due(now)
+	if len(due) == 0 {
+		return
+	}
+
+	if e.needsPoll(now, due) {
+		pending := e.poll(ctx, now)
+		if pending && config.Default.Viper().GetBool(config.ConfigurationKey_StatusConfirmRecheck) {
+			// confirm the pending transitions right away instead of waiting
+			// for the next poll
+			e.poll(ctx, time.Now())
+		}
+	}
+
+	// statuses and payloads are shared by subscribers with the same due paths
+	// and are therefore only computed and encoded once
+	statuses := make(map[string][]v1.ServiceStatus)
+	payloads := make(map[string][]byte)
+	encode := func(key string, v any) []byte {
+		if payload, ok := payloads[key]; ok {
+			return payload
+		}
+		payload, err := json.Marshal(v)
+		if err != nil {
+			slog.Error("unable to encode status payload", "error", err)
+			return nil
+		}
+		payloads[key] = payload
+		return payload
+	}
+
+	for _, s := range due {
+		duePaths := s.due(now)
+		if len(duePaths) == 0 {
+			continue
+		}
+
+		key := groupKey(duePaths)
+		current, ok := statuses[key]
+		if !ok {
+			current = e.statuses(duePaths, now)
+			statuses[key] = current
+		}
+
+		if needsSnapshot(duePaths) {
+			frame := v1.Envelope{
+				Type:      v1.FrameTypeSnapshot,
+				RelatedTo: snapshotRelatedTo(duePaths),
+				Data:      encode("snapshot\x00"+key, current),
+			}
+			s.deliver(frame, statusPaths(current), duePaths, current, now)
+			continue
+		}
+
+		transitions := s.transitions(current)
+		if len(transitions) == 0 {
+			s.deliver(v1.Envelope{}, nil, duePaths, current, now)
+			continue
+		}
+		frame := v1.Envelope{Type: v1.FrameTypeUpdate, Data: encode(transitionsKey(transitions), transitions)}
+		s.deliver(frame, transitionPaths(transitions), duePaths, current, now)
+	}
+}
+
+// needsPoll reports if the Traefik API needs to be queried to serve the due
+// subscribers.
+// The API is polled at most once per configured minimal poll interval, unless
+// a subscriber requests a path which has not been cached yet.
+func (e *Engine) needsPoll(now time.Time, due []*Subscriber) bool {
+	e.cacheLock.RLock()
+	defer e.cacheLock.RUnlock()
+
+	for _, s := range due {
+		for _, path := range s.Paths() {
+			if _, cached := e.cache[path]; !cached {
+				return true
+			}
+		}
+	}
+
+	minInterval := config.Default.Viper().GetDuration(config.ConfigurationKey_MonitorMinPollInterval)
+	return now.Sub(e.lastPoll) >= minInterval
+}
+
+// poll queries the statuses of all subscribed paths and replaces the cache.
+// If the poll fails, the last known statuses are kept. They are marked as
+// stale once Traefik is considered unavailable, i.e. after the failure
+// threshold of the circuit breaker has been reached. Paths without a known
+// status are reported with the unknown status.
+// The returned bool reports if a status transition awaits confirmation.
+func (e *Engine) poll(ctx context.Context, now time.Time) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	e.pollLock.Lock()
+	e.pollCancel = cancel
+	e.pollLock.Unlock()
+
+	defer func() {
+		e.pollLock.Lock()
+		e.pollCancel = nil
+		e.pollLock.Unlock()
+		cancel()
+	}()
+
+	paths := e.hub.Paths()
+	statuses, roundTrip, err := e.client.ServiceStatus(ctx, paths...)
+
+	var connectTimes map[string]time.Duration
+	if err == nil {
+		connectTimes = measureUpstreams(ctx, statuses)
+	}
+
+	// a canceled poll (e.g. after the last subscriber left) does not tell
+	// anything about the gateway, so the cached statuses are kept
+	if errors.Is(ctx.Err(), context.Canceled) {
+		slog.Debug("polling service status from traefik canceled")
+		return false
+	}
+
+	e.cacheLock.Lock()
+	defer e.cacheLock.Unlock()
+
+	if err != nil {
+		if errors.Is(err, traefik.ErrCircuitOpen) {
+			slog.Debug("skipped polling service status from traefik", "error", err)
+		} else {
+			slog.Warn("unable to poll service status from traefik", "error", err)
+		}
+		statuses = e.staleStatuses(paths, err, e.client.Unavailable(), now)
+	} else {
+		e.gatewayLatency.add(roundTrip, now)
+		e.watchCertificates(statuses)
+	}
+
+	cache := make(map[string]v1.ServiceStatus, len(statuses))
+	anyPending := false
+	for _, status := range statuses {
+		if err == nil {
+			e.trackUpstreams(status.Upstreams, now)
+			status = e.applyProbe(status)
+			status = e.recordLatency(status, connectTimes, now)
+			status = applyLatencyThreshold(status)
+			status = e.applyCertificates(status)
+
+			var pending bool
+			status, pending = e.damp(status, now)
+			anyPending = anyPending || pending
+		}
+		cache[status.Path] = applyMaintenance(status)
+	}
+	if err == nil {
+		e.pruneUpstreams(now)
+		e.pruneLatencies(cache)
+		for path := range e.damping {
+			if _, polled := cache[path]; !polled {
+				delete(e.damping, path)
+			}
+		}
+	}
+
+	e.cache = cache
+	e.lastPoll = now
+	return anyPending
+}
+
+// staleStatuses returns the last known statuses of the paths, which are marked
+// as stale if Traefik is unavailable.
+// The caller needs to hold the cache lock.
+func (e *Engine) staleStatuses(paths []string, pollErr error, unavailable bool, now time.Time) []v1.ServiceStatus { //nolint:lll
+	statuses := make([]v1.ServiceStatus, 0, len(paths))
+	for _, path := range paths {
+		status, ok := e.cache[path]
+		if !ok || status.Status == v1.ServiceStatusUnknown {
+			statuses = append(statuses, v1.ServiceStatus{
+				Path:       path,
+				LastUpdate: now,
+				Status:     v1.ServiceStatusUnknown,
+				Reason:     v1.ReasonGatewayUnreachable,
+				Message:    pollErr.Error(),
+			})
+			continue
+		}
+
+		status.Stale = status.Stale || unavailable
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// applyProbe combines the latest probe result of the path with its status.
+func (e *Engine) applyProbe(status v1.ServiceStatus) v1.ServiceStatus {
+	result, ok := e.probes.Result(status.Path)
+	if !ok {
+		return status
+	}
+
+	status.Probe = &result
+	if worse := v1.WorseStatus(status.Status, result.Status); worse != status.Status {
+		status.Status = worse
+		status.Reason = v1.ReasonProbeFailed
+		status.Message = strings.Join(result.Errors, "; ")
+	}
+	return status
+}
+
+// applyMaintenance replaces the status of paths which are configured to be in
+// maintenance.
+func applyMaintenance(status v1.ServiceStatus) v1.ServiceStatus {
+	settings := config.Default.PathSettings(status.Path)
+	if !settings.Maintenance {
+		return status
+	}
+
+	status.Status = v1.ServiceStatusMaintenance
+	status.Reason = v1.ReasonMaintenance
+	status.Message = settings.MaintenanceMessage
+	if status.Message == "" {
+		status.Message = "the path is in maintenance"
+	}
+	return status
+}
+
+func (e *Engine) statuses(due []duePath, now time.Time) []v1.ServiceStatus {
+	e.cacheLock.RLock()
+	defer e.cacheLock.RUnlock()
+
+	statuses := make([]v1.ServiceStatus, 0, len(due))
+	for _, d := range due {
+		status, ok := e.cache[d.path]
+		if !ok {
+			continue
+		}
+		if status.Stale {
+			status.Age = duration.Format(now.Sub(status.LastUpdate).Truncate(time.Second))
+		}
+		if !d.options.Detailed {
+			status = status.Summary()
+		}
+		if !d.options.ExtendedStatuses {
+			status = status.Legacy()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
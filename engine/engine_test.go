@@ -0,0 +1,308 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "microservice/internal/configuration"
+	"microservice/probe"
+	"microservice/traefik"
+	v1 "microservice/types/v1"
+)
+
+const testRawData = `{
+	"routers": {
+		"api@docker": {"rule": "PathPrefix(` + "`/api`" + `)", "service": "api@internal", "status": "enabled"}
+	},
+	"services": {
+		"api@internal": {"status": "enabled"}
+	}
+}`
+
+// fakeTraefik serves the rawdata endpoint with the configured status code.
+type fakeTraefik struct {
+	requests atomic.Int32
+	status   atomic.Int32
+}
+
+// setConfig overrides the configuration key for the duration of the test.
+func setConfig(t *testing.T, key string, value any) {
+	t.Helper()
+
+	c := config.Default.Viper()
+	previous := c.Get(key)
+	c.Set(key, value)
+	t.Cleanup(func() { c.Set(key, previous) })
+}
+
+// newTestEngine creates an engine polling a fake Traefik API. The circuit
+// breaker of its client opens after two failures.
+func newTestEngine(t *testing.T) (*Engine, *fakeTraefik) {
+	t.Helper()
+
+	setConfig(t, config.ConfigurationKey_MonitorMinPollInterval, time.Minute)
+	setConfig(t, config.ConfigurationKey_LatencyMeasureUpstreams, false)
+
+	fake := &fakeTraefik{}
+	fake.status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.requests.Add(1)
+		if status := int(fake.status.Load()); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(testRawData))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := traefik.NewClient(traefik.ClientOptions{
+		Endpoint:         server.URL,
+		FailureThreshold: 2,
+		InitialBackoff:   time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return New(client, probe.NewRunner()), fake
+}
+
+// receive returns the frame waiting for the subscriber, if any.
+func receive(s *Subscriber) (v1.Envelope, bool) {
+	select {
+	case frame := <-s.C():
+		return frame, true
+	default:
+		return v1.Envelope{}, false
+	}
+}
+
+// receiveStatuses decodes the snapshot waiting for the subscriber.
+func receiveStatuses(t *testing.T, s *Subscriber) ([]v1.ServiceStatus, []byte) {
+	t.Helper()
+
+	frame, ok := receive(s)
+	if !ok {
+		t.Fatal("no frame delivered to the subscriber")
+	}
+	if frame.Type != v1.FrameTypeSnapshot {
+		t.Fatalf("frame type = %s, want %s", frame.Type, v1.FrameTypeSnapshot)
+	}
+
+	var statuses []v1.ServiceStatus
+	if err := json.Unmarshal(frame.Data, &statuses); err != nil {
+		t.Fatalf("unable to decode snapshot: %v", err)
+	}
+	return statuses, frame.Data
+}
+
+func TestTickPollsOnce(t *testing.T) {
+	e, fake := newTestEngine(t)
+
+	plain, shared, detailed := NewSubscriber(), NewSubscriber(), NewSubscriber()
+	e.Subscribe(plain, []string{"/api"}, Options{Interval: time.Second})
+	e.Subscribe(shared, []string{"/api"}, Options{Interval: time.Second})
+	e.Subscribe(detailed, []string{"/api"}, Options{Interval: time.Second, Detailed: true})
+
+	e.tick(context.Background(), time.Now())
+	if requests := fake.requests.Load(); requests != 1 {
+		t.Errorf("requests = %d, want a single poll for all subscribers", requests)
+	}
+
+	plainStatuses, plainPayload := receiveStatuses(t, plain)
+	_, sharedPayload := receiveStatuses(t, shared)
+	detailedStatuses, detailedPayload := receiveStatuses(t, detailed)
+
+	if len(plainStatuses) != 1 || plainStatuses[0].Status != v1.ServiceStatusOk {
+		t.Errorf("statuses = %+v, want /api to be ok", plainStatuses)
+	}
+	if &plainPayload[0] != &sharedPayload[0] {
+		t.Error("subscribers with the same paths and options received separately encoded payloads")
+	}
+	if &plainPayload[0] == &detailedPayload[0] {
+		t.Error("subscribers with different options share the payload")
+	}
+	if len(detailedStatuses) != 1 || detailedStatuses[0].Provider != "internal" {
+		t.Errorf("detailed statuses = %+v, want the provider of the service", detailedStatuses)
+	}
+}
+
+func TestTickServesCachedStatuses(t *testing.T) {
+	e, fake := newTestEngine(t)
+	now := time.Now()
+
+	first := NewSubscriber()
+	e.Subscribe(first, []string{"/api"}, Options{Interval: time.Second})
+	e.tick(context.Background(), now)
+	receiveStatuses(t, first)
+
+	// the cached status is served to new subscribers within the minimal poll
+	// interval
+	second := NewSubscriber()
+	e.Subscribe(second, []string{"/api"}, Options{Interval: time.Second})
+	e.tick(context.Background(), now.Add(time.Second))
+	if requests := fake.requests.Load(); requests != 1 {
+		t.Errorf("requests = %d, want the cached statuses to be used", requests)
+	}
+	if statuses, _ := receiveStatuses(t, second); len(statuses) != 1 || statuses[0].Status != v1.ServiceStatusOk {
+		t.Errorf("statuses = %+v, want the cached status of /api", statuses)
+	}
+	if frame, ok := receive(first); ok {
+		t.Errorf("unexpected frame %s for an unchanged status", frame.Type)
+	}
+
+	// paths which have not been cached yet are polled right away
+	third := NewSubscriber()
+	e.Subscribe(third, []string{"/missing"}, Options{Interval: time.Second})
+	e.tick(context.Background(), now.Add(2*time.Second))
+	if requests := fake.requests.Load(); requests != 2 {
+		t.Errorf("requests = %d, want a poll for the uncached path", requests)
+	}
+	if statuses, _ := receiveStatuses(t, third); len(statuses) != 1 || statuses[0].Reason != v1.ReasonNoMatchingRouter {
+		t.Errorf("statuses = %+v, want /missing without router", statuses)
+	}
+}
+
+func TestTickServesStaleStatuses(t *testing.T) {
+	e, fake := newTestEngine(t)
+	now := time.Now()
+
+	s := NewSubscriber()
+	e.Subscribe(s, []string{"/api"}, Options{Interval: time.Second})
+	e.tick(context.Background(), now)
+	receiveStatuses(t, s)
+
+	fake.status.Store(http.StatusBadGateway)
+
+	// a single failed poll keeps the cached status as it is
+	e.tick(context.Background(), now.Add(time.Minute))
+	if frame, ok := receive(s); ok {
+		t.Errorf("unexpected frame %s after a single failed poll", frame.Type)
+	}
+	if status, _ := e.Status("/api"); status.Stale {
+		t.Error("status marked as stale after a single failed poll")
+	}
+
+	// reaching the failure threshold marks the status as stale
+	e.tick(context.Background(), now.Add(2*time.Minute))
+	frame, ok := receive(s)
+	if !ok || frame.Type != v1.FrameTypeUpdate {
+		t.Fatalf("frame = %+v, want an update", frame)
+	}
+	var transitions []v1.Transition
+	if err := json.Unmarshal(frame.Data, &transitions); err != nil {
+		t.Fatalf("unable to decode update: %v", err)
+	}
+	if len(transitions) != 1 || !transitions[0].Stale || transitions[0].Status != v1.ServiceStatusOk {
+		t.Fatalf("transitions = %+v, want /api to become stale", transitions)
+	}
+	if transitions[0].Age == "" {
+		t.Error("stale transition without age")
+	}
+}
+
+func TestStaleStatuses(t *testing.T) {
+	now := time.Now()
+	e := &Engine{cache: map[string]v1.ServiceStatus{
+		"/ok":      {Path: "/ok", Status: v1.ServiceStatusOk, LastUpdate: now.Add(-time.Minute)},
+		"/unknown": {Path: "/unknown", Status: v1.ServiceStatusUnknown},
+	}}
+	paths := []string{"/ok", "/unknown", "/new"}
+	pollErr := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		unavailable bool
+		wantStale   bool
+	}{
+		{"single failed poll", false, false},
+		{"unavailable traefik", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := e.staleStatuses(paths, pollErr, tt.unavailable, now)
+			if len(statuses) != len(paths) {
+				t.Fatalf("statuses = %+v, want one per path", statuses)
+			}
+
+			if ok := statuses[0]; ok.Status != v1.ServiceStatusOk || ok.Stale != tt.wantStale {
+				t.Errorf("cached status = %s, stale %t, want %s, stale %t",
+					ok.Status, ok.Stale, v1.ServiceStatusOk, tt.wantStale)
+			}
+			for _, status := range statuses[1:] {
+				if status.Status != v1.ServiceStatusUnknown || status.Reason != v1.ReasonGatewayUnreachable {
+					t.Errorf("status of %s = %s (%s), want %s (%s)", status.Path, status.Status, status.Reason,
+						v1.ServiceStatusUnknown, v1.ReasonGatewayUnreachable)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionCommands(t *testing.T) {
+	setConfig(t, config.ConfigurationKey_MonitorMinPollInterval, 5*time.Second)
+	e := New(nil, nil)
+	s := NewSubscriber()
+
+	e.Subscribe(s, []string{"/b", "/a"}, Options{Interval: time.Minute})
+	e.Subscribe(s, []string{"/c"}, Options{Interval: time.Minute})
+	if want := []string{"/a", "/b", "/c"}; !slices.Equal(s.Paths(), want) {
+		t.Errorf("paths after subscribing = %v, want %v", s.Paths(), want)
+	}
+	if want := []string{"/a", "/b", "/c"}; !slices.Equal(e.Hub().Paths(), want) {
+		t.Errorf("hub paths = %v, want %v", e.Hub().Paths(), want)
+	}
+
+	e.Replace(s, []string{"/d"}, Options{Interval: time.Minute})
+	if want := []string{"/d"}; !slices.Equal(s.Paths(), want) {
+		t.Errorf("paths after replacing = %v, want %v", s.Paths(), want)
+	}
+
+	e.Subscribe(s, []string{"/e"}, Options{Interval: time.Minute})
+	e.Unsubscribe(s, "/d", "/unknown")
+	if want := []string{"/e"}; !slices.Equal(s.Paths(), want) {
+		t.Errorf("paths after unsubscribing = %v, want %v", s.Paths(), want)
+	}
+
+	e.Unsubscribe(s)
+	if len(s.Paths()) != 0 || len(e.Hub().Paths()) != 0 {
+		t.Errorf("paths after unsubscribing all = %v, hub %v, want none", s.Paths(), e.Hub().Paths())
+	}
+	if _, ok := e.Hub().nextDue(); ok {
+		t.Error("subscriber still attached after unsubscribing all paths")
+	}
+}
+
+func TestSubscribeIntervals(t *testing.T) {
+	setConfig(t, config.ConfigurationKey_MonitorMinPollInterval, 5*time.Second)
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"default interval", 0, DefaultInterval},
+		{"requested interval", time.Minute, time.Minute},
+		{"raised to the minimal poll interval", time.Microsecond, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(nil, nil)
+			s := NewSubscriber()
+			e.Subscribe(s, []string{"/api"}, Options{Interval: tt.interval})
+
+			due := s.due(time.Now())
+			if len(due) != 1 || due[0].options.Interval != tt.want {
+				t.Errorf("due = %+v, want the interval %s", due, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+// Hub keeps track of the subscribers currently attached to the engine.
+type Hub struct {
+	lock        sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+}
+
+func newHub() *Hub {
+	return &Hub{subscribers: make(map[*Subscriber]struct{})}
+}
+
+func (h *Hub) add(s *Subscriber) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.subscribers[s] = struct{}{}
+}
+
+func (h *Hub) remove(s *Subscriber) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.subscribers, s)
+}
+
+// Paths returns the union of all paths subscribed by the attached subscribers.
+func (h *Hub) Paths() []string {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	seen := make(map[string]struct{})
+	for s := range h.subscribers {
+		for _, path := range s.Paths() {
+			seen[path] = struct{}{}
+		}
+	}
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// due returns the subscribers which are due for an update at the supplied
+// point in time.
+func (h *Hub) due(now time.Time) []*Subscriber {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	var due []*Subscriber
+	for s := range h.subscribers {
+		if s.isDue(now) {
+			due = append(due, s)
+		}
+	}
+	return due
+}
+
+// nextDue returns the earliest point in time a subscriber is due for an update.
+// If no subscriber is attached, the returned bool is false.
+func (h *Hub) nextDue() (time.Time, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	var next time.Time
+	found := false
+	for s := range h.subscribers {
+		d, ok := s.dueAt()
+		if !ok {
+			continue
+		}
+		if !found || d.Before(next) {
+			next = d
+			found = true
+		}
+	}
+	return next, found
+}
+
+// groupKey builds a key identifying the due paths of a subscriber and the form
+// in which their statuses are sent.
+// Subscribers sharing a key receive the same payload which therefore only
+// needs to be encoded once.
+func groupKey(due []duePath) string {
+	var key strings.Builder
+	for _, d := range due {
+		key.WriteString(d.path)
+		key.WriteString("\x00")
+		key.WriteString(strconv.FormatBool(d.options.Detailed))
+		key.WriteString("\x00")
+		key.WriteString(strconv.FormatBool(d.options.ExtendedStatuses))
+		key.WriteString("\x01")
+	}
+	return key.String()
+}
+
+// statusPaths returns the paths of the statuses.
+func statusPaths(statuses []v1.ServiceStatus) []string {
+	paths := make([]string, len(statuses))
+	for idx, status := range statuses {
+		paths[idx] = status.Path
+	}
+	return paths
+}
+
+// transitionPaths returns the paths of the transitions.
+func transitionPaths(transitions []v1.Transition) []string {
+	paths := make([]string, len(transitions))
+	for idx, t := range transitions {
+		paths[idx] = t.Path
+	}
+	return paths
+}
+
+// transitionsKey builds a key identifying the transitions, allowing
+// subscribers observing the same transitions to share the encoded payload.
+func transitionsKey(transitions []v1.Transition) string {
+	var key strings.Builder
+	for _, t := range transitions {
+		key.WriteString(t.Path)
+		key.WriteString("\x00")
+		key.WriteString(t.Previous)
+		key.WriteString("\x00")
+		key.WriteString(t.Status)
+		key.WriteString("\x00")
+		key.WriteString(t.Timestamp.String())
+		key.WriteString("\x00")
+		key.WriteString(strconv.FormatBool(t.Stale))
+		key.WriteString("\x00")
+		key.WriteString(t.Age)
+		key.WriteString("\x01")
+	}
+	return key.String()
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	config "microservice/internal/configuration"
+	"microservice/probe"
+	"microservice/traefik"
+	v1 "microservice/types/v1"
+)
+
+// latencyWindow keeps the latest latency samples of a single source.
+type latencyWindow struct {
+	samples []time.Duration
+	seen    time.Time // the point in time of the latest sample
+}
+
+// add records the sample and drops the oldest samples exceeding the configured
+// number of samples.
+func (w *latencyWindow) add(sample time.Duration, at time.Time) {
+	size := max(config.Default.Viper().GetInt(config.ConfigurationKey_LatencySamples), 1)
+	w.seen = at
+	w.samples = append(w.samples, sample)
+	if len(w.samples) > size {
+		w.samples = slices.Clone(w.samples[len(w.samples)-size:])
+	}
+}
+
+// percentiles summarizes the samples of the windows.
+func percentiles(windows ...*latencyWindow) *v1.LatencyPercentiles {
+	var samples []time.Duration
+	for _, w := range windows {
+		if w != nil {
+			samples = append(samples, w.samples...)
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	slices.Sort(samples)
+
+	// nearest-rank method
+	rank := func(p float64) int64 {
+		idx := int(math.Ceil(p/100*float64(len(samples)))) - 1 //nolint:mnd
+		return samples[max(idx, 0)].Milliseconds()
+	}
+
+	return &v1.LatencyPercentiles{
+		Samples: len(samples),
+		P50Ms:   rank(50), //nolint:mnd
+		P95Ms:   rank(95), //nolint:mnd
+		P99Ms:   rank(99), //nolint:mnd
+	}
+}
+
+// LatencyThresholdFor returns the p95 latency above which the supplied path is
+// reported as limited. A threshold of zero disables the degradation.
+func LatencyThresholdFor(path string) time.Duration {
+	if threshold := config.Default.PathSettings(path).P95Threshold; threshold != nil {
+		return *threshold
+	}
+	return config.Default.Viper().GetDuration(config.ConfigurationKey_LatencyP95Threshold)
+}
+
+// measureUpstreams measures the tcp connect time to every upstream of the
+// statuses. Upstreams which cannot be reached are omitted, since their
+// availability is reported by the Traefik health checks.
+func measureUpstreams(ctx context.Context, statuses []v1.ServiceStatus) map[string]time.Duration {
+	c := config.Default.Viper()
+	if !c.GetBool(config.ConfigurationKey_LatencyMeasureUpstreams) {
+		return nil
+	}
+
+	unique := make(map[string]struct{})
+	for _, status := range statuses {
+		if traefik.ParseTarget(status.Path).Protocol == traefik.ProtocolUDP {
+			// udp upstreams cannot be connected to
+			continue
+		}
+		for _, upstream := range status.Upstreams {
+			unique[upstream.Url] = struct{}{}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.GetDuration(config.ConfigurationKey_LatencyConnectTimeout))
+	defer cancel()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	measured := make(map[string]time.Duration, len(unique))
+	for upstream := range unique {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			elapsed, err := probe.Connect(ctx, upstream)
+			if err != nil {
+				return
+			}
+			lock.Lock()
+			measured[upstream] = elapsed
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return measured
+}
+
+// recordLatency records the latencies observed for the status and sets its
+// latency percentiles.
+// The caller needs to hold the cache lock.
+func (e *Engine) recordLatency(status v1.ServiceStatus, connectTimes map[string]time.Duration, now time.Time) v1.ServiceStatus { //nolint:lll
+	if status.Probe != nil {
+		w, ok := e.probeLatency[status.Path]
+		if !ok {
+			w = &latencyWindow{}
+			e.probeLatency[status.Path] = w
+		}
+		if w.seen.Before(status.Probe.CheckedAt) {
+			w.add(time.Duration(status.Probe.LatencyMs)*time.Millisecond, status.Probe.CheckedAt)
+		}
+	}
+
+	upstreamWindows := make([]*latencyWindow, 0, len(status.Upstreams))
+	for idx := range status.Upstreams {
+		upstream := &status.Upstreams[idx]
+		w, ok := e.upstreamLatency[upstream.Url]
+		if !ok {
+			w = &latencyWindow{}
+			e.upstreamLatency[upstream.Url] = w
+		}
+		if elapsed, measured := connectTimes[upstream.Url]; measured {
+			if w.seen.Before(now) {
+				w.add(elapsed, now)
+			}
+			connectMs := elapsed.Milliseconds()
+			upstream.ConnectMs = &connectMs
+		}
+		upstreamWindows = append(upstreamWindows, w)
+	}
+
+	status.Latency = &v1.Latency{
+		Gateway:   percentiles(e.gatewayLatency),
+		Probe:     percentiles(e.probeLatency[status.Path]),
+		Upstreams: percentiles(upstreamWindows...),
+	}
+
+	return status
+}
+
+// applyLatencyThreshold degrades the status if the p95 latency of the path
+// exceeds the configured threshold.
+// The latency of the synthetic probes is preferred, as it contains the full
+// round trip through the gateway.
+func applyLatencyThreshold(status v1.ServiceStatus) v1.ServiceStatus {
+	threshold := LatencyThresholdFor(status.Path)
+	if threshold <= 0 || status.Latency == nil {
+		return status
+	}
+
+	source, stats := "probe", status.Latency.Probe
+	if stats == nil {
+		source, stats = "upstream connect", status.Latency.Upstreams
+	}
+	if stats == nil || time.Duration(stats.P95Ms)*time.Millisecond <= threshold {
+		return status
+	}
+
+	if worse := v1.WorseStatus(status.Status, v1.ServiceStatusIssues); worse != status.Status {
+		status.Status = worse
+		status.Reason = v1.ReasonLatencyDegraded
+		status.Message = fmt.Sprintf("p95 %s latency of %dms exceeds %s", source, stats.P95Ms, threshold)
+	}
+	return status
+}
+
+// pruneLatencies removes the latency windows of paths and upstreams which
+// have not been observed in the last poll.
+// The caller needs to hold the cache lock.
+func (e *Engine) pruneLatencies(cache map[string]v1.ServiceStatus) {
+	observed := make(map[string]struct{})
+	for _, status := range cache {
+		for _, upstream := range status.Upstreams {
+			observed[upstream.Url] = struct{}{}
+		}
+	}
+
+	for path := range e.probeLatency {
+		if _, ok := cache[path]; !ok {
+			delete(e.probeLatency, path)
+		}
+	}
+	for upstream := range e.upstreamLatency {
+		if _, ok := observed[upstream]; !ok {
+			delete(e.upstreamLatency, upstream)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// windowOf creates a window containing the samples given in milliseconds.
+func windowOf(samples ...int) *latencyWindow {
+	w := &latencyWindow{}
+	for _, sample := range samples {
+		w.samples = append(w.samples, time.Duration(sample)*time.Millisecond)
+	}
+	return w
+}
+
+func TestPercentiles(t *testing.T) {
+	hundred := make([]int, 100)
+	for idx := range hundred {
+		// reversed to ensure the samples are sorted
+		hundred[idx] = 100 - idx
+	}
+
+	tests := []struct {
+		name    string
+		windows []*latencyWindow
+		want    *v1.LatencyPercentiles
+	}{
+		{"no windows", nil, nil},
+		{"empty windows", []*latencyWindow{nil, windowOf()}, nil},
+		{"single sample", []*latencyWindow{windowOf(7)}, &v1.LatencyPercentiles{Samples: 1, P50Ms: 7, P95Ms: 7, P99Ms: 7}},
+		{
+			name:    "nearest rank of few samples",
+			windows: []*latencyWindow{windowOf(40, 10, 30, 20)},
+			want:    &v1.LatencyPercentiles{Samples: 4, P50Ms: 20, P95Ms: 40, P99Ms: 40},
+		},
+		{
+			name:    "nearest rank of a hundred samples",
+			windows: []*latencyWindow{windowOf(hundred...)},
+			want:    &v1.LatencyPercentiles{Samples: 100, P50Ms: 50, P95Ms: 95, P99Ms: 99},
+		},
+		{
+			name:    "samples of several windows are combined",
+			windows: []*latencyWindow{windowOf(1, 2), nil, windowOf(3, 4, 5)},
+			want:    &v1.LatencyPercentiles{Samples: 5, P50Ms: 3, P95Ms: 5, P99Ms: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := percentiles(tt.windows...)
+			switch {
+			case got == nil || tt.want == nil:
+				if got != tt.want {
+					t.Errorf("percentiles() = %+v, want %+v", got, tt.want)
+				}
+			case *got != *tt.want:
+				t.Errorf("percentiles() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestLatencyWindowKeepsLatestSamples(t *testing.T) {
+	setConfig(t, config.ConfigurationKey_LatencySamples, 3)
+
+	w := &latencyWindow{}
+	now := time.Now()
+	for sample := range 5 {
+		w.add(time.Duration(sample)*time.Millisecond, now.Add(time.Duration(sample)*time.Second))
+	}
+
+	want := []time.Duration{2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond}
+	if !slices.Equal(w.samples, want) {
+		t.Errorf("samples = %v, want %v", w.samples, want)
+	}
+	if !w.seen.Equal(now.Add(4 * time.Second)) {
+		t.Errorf("seen = %s, want the time of the latest sample", w.seen)
+	}
+}
+
+func TestApplyLatencyThreshold(t *testing.T) {
+	setConfig(t, config.ConfigurationKey_LatencyP95Threshold, 100*time.Millisecond)
+
+	tests := []struct {
+		name       string
+		latency    *v1.Latency
+		wantStatus string
+	}{
+		{"no latency", nil, v1.ServiceStatusOk},
+		{"below threshold", &v1.Latency{Probe: &v1.LatencyPercentiles{P95Ms: 100}}, v1.ServiceStatusOk},
+		{"probe above threshold", &v1.Latency{Probe: &v1.LatencyPercentiles{P95Ms: 101}}, v1.ServiceStatusIssues},
+		{
+			name:       "probe preferred over upstreams",
+			latency:    &v1.Latency{Probe: &v1.LatencyPercentiles{P95Ms: 50}, Upstreams: &v1.LatencyPercentiles{P95Ms: 500}},
+			wantStatus: v1.ServiceStatusOk,
+		},
+		{"upstreams above threshold", &v1.Latency{Upstreams: &v1.LatencyPercentiles{P95Ms: 500}}, v1.ServiceStatusIssues},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := applyLatencyThreshold(v1.ServiceStatus{Path: "/api", Status: v1.ServiceStatusOk, Latency: tt.latency})
+			if status.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", status.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == v1.ServiceStatusIssues && status.Reason != v1.ReasonLatencyDegraded {
+				t.Errorf("reason = %s, want %s", status.Reason, v1.ReasonLatencyDegraded)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package engine
+
+import (
+	"os"
+	"testing"
+
+	config "microservice/internal/configuration"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.Default.Initialize(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,283 @@
+package engine
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+// Options configure how a subscriber receives the updates of a path.
+type Options struct {
+	// Interval between two updates. If zero, the [DefaultInterval] is used
+	Interval time.Duration
+
+	// Detailed enables the details about the service and its upstreams
+	Detailed bool
+
+	// ExtendedStatuses enables statuses besides the legacy statuses. If
+	// disabled, the extended statuses are mapped to the legacy statuses
+	ExtendedStatuses bool
+
+	// Snapshots enables sending the full statuses on every update. Otherwise,
+	// the full statuses are only sent once and afterwards only the
+	// transitions of the statuses are sent
+	Snapshots bool
+
+	// RelatedTo contains the id of the command subscribing the paths. The
+	// first snapshot containing the paths relates to the command
+	RelatedTo v1.CommandID
+}
+
+// subscription contains the options a single path has been subscribed with.
+// The status and stale flag last sent to the subscriber are kept to detect
+// transitions.
+type subscription struct {
+	options        Options
+	lastDelivery   time.Time
+	delivered      string
+	deliveredStale bool
+
+	// relatedTo is the id of the subscribing command until the first
+	// snapshot of the path has been delivered
+	relatedTo v1.CommandID
+}
+
+// duePath is a subscribed path which is due for an update.
+type duePath struct {
+	path    string
+	options Options
+
+	// snapshot is set if the full status of the path needs to be sent
+	snapshot bool
+
+	// relatedTo is the id of the command the snapshot of the path responds to
+	relatedTo v1.CommandID
+}
+
+// needsSnapshot reports if the full statuses need to be sent since a path
+// has not been sent before or has been subscribed with snapshots enabled.
+func needsSnapshot(due []duePath) bool {
+	return slices.ContainsFunc(due, func(d duePath) bool {
+		return d.snapshot
+	})
+}
+
+// snapshotRelatedTo returns the id of a command the snapshot of the due paths
+// responds to. If the snapshot responds to several commands, the id of the
+// first path subscribed by a command is used.
+func snapshotRelatedTo(due []duePath) v1.CommandID {
+	for _, d := range due {
+		if d.relatedTo != "" {
+			return d.relatedTo
+		}
+	}
+	return ""
+}
+
+// Subscriber represents a single consumer of status updates (e.g. a websocket
+// connection).
+// Every path keeps the options it has been subscribed with, allowing the paths
+// to be updated in different intervals.
+// The frames are delivered using the channel returned by [Subscriber.C]. Their
+// sequence number is left to the connection sending them.
+type Subscriber struct {
+	lock  sync.Mutex
+	paths map[string]*subscription
+
+	// pending contains the paths of the frame not yet consumed
+	pending []string
+
+	c chan v1.Envelope
+}
+
+// NewSubscriber creates a new subscriber without any subscribed paths.
+func NewSubscriber() *Subscriber {
+	return &Subscriber{
+		paths: make(map[string]*subscription),
+		c:     make(chan v1.Envelope, 1),
+	}
+}
+
+// C returns the channel on which the frames are delivered.
+func (s *Subscriber) C() <-chan v1.Envelope {
+	return s.c
+}
+
+// Paths returns the sorted paths the subscriber is interested in.
+func (s *Subscriber) Paths() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	paths := make([]string, 0, len(s.paths))
+	for path := range s.paths {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// add subscribes the paths using the options. Paths which are already
+// subscribed use the new options and receive an update as soon as possible.
+func (s *Subscriber) add(paths []string, options Options) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	relatedTo := options.RelatedTo
+	options.RelatedTo = ""
+	for _, path := range paths {
+		s.paths[path] = &subscription{options: options, relatedTo: relatedTo}
+	}
+}
+
+// remove unsubscribes the paths. If no paths are supplied, all paths are
+// unsubscribed.
+// The returned bool reports if the subscriber has paths left.
+func (s *Subscriber) remove(paths ...string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(paths) == 0 {
+		clear(s.paths)
+	}
+	for _, path := range paths {
+		delete(s.paths, path)
+	}
+	return len(s.paths) > 0
+}
+
+// dueAt returns the earliest point in time a path is due for an update.
+// If no path is subscribed, the returned bool is false.
+func (s *Subscriber) dueAt() (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var next time.Time
+	found := false
+	for _, sub := range s.paths {
+		d := sub.lastDelivery.Add(sub.options.Interval)
+		if !found || d.Before(next) {
+			next = d
+			found = true
+		}
+	}
+	return next, found
+}
+
+func (s *Subscriber) isDue(now time.Time) bool {
+	next, ok := s.dueAt()
+	return ok && !next.After(now)
+}
+
+// due returns the paths which are due for an update, sorted by path.
+func (s *Subscriber) due(now time.Time) []duePath {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var due []duePath
+	for path, sub := range s.paths {
+		if !sub.lastDelivery.Add(sub.options.Interval).After(now) {
+			due = append(due, duePath{
+				path:      path,
+				options:   sub.options,
+				snapshot:  sub.options.Snapshots || sub.delivered == "",
+				relatedTo: sub.relatedTo,
+			})
+		}
+	}
+	slices.SortFunc(due, func(a, b duePath) int {
+		return cmp.Compare(a.path, b.path)
+	})
+	return due
+}
+
+// transitions returns the transitions of the statuses compared to the
+// statuses last sent to the subscriber. A status becoming stale or being
+// refreshed is a transition as well.
+func (s *Subscriber) transitions(statuses []v1.ServiceStatus) []v1.Transition {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var transitions []v1.Transition
+	for _, status := range statuses {
+		sub, ok := s.paths[status.Path]
+		if !ok || (sub.delivered == status.Status && sub.deliveredStale == status.Stale) {
+			continue
+		}
+		transitions = append(transitions, v1.Transition{
+			Path:      status.Path,
+			Previous:  sub.delivered,
+			Status:    status.Status,
+			Reason:    status.Reason,
+			Message:   status.Message,
+			Timestamp: status.LastUpdate,
+			Stale:     status.Stale,
+			Age:       status.Age,
+		})
+	}
+	return transitions
+}
+
+// deliver hands the frame containing the statuses of the due paths to the
+// subscriber without blocking the engine. The contained paths are the paths
+// the frame carries a status or transition for. If the frame has no data, the
+// paths are only marked as delivered, since nothing changed.
+// If the subscriber has not yet consumed the previous frame, it is replaced
+// with the newer one. The paths contained in the replaced frame are due again
+// immediately and are sent as full statuses, unless the newer frame is a
+// snapshot containing them as well. The id of the command the replaced frame
+// responds to is kept for the snapshot sending these paths.
+func (s *Subscriber) deliver(frame v1.Envelope, contained []string, due []duePath, statuses []v1.ServiceStatus, at time.Time) { //nolint:lll
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, d := range due {
+		if sub, ok := s.paths[d.path]; ok {
+			sub.lastDelivery = at
+			if frame.Type == v1.FrameTypeSnapshot {
+				sub.relatedTo = ""
+			}
+		}
+	}
+	for _, status := range statuses {
+		if sub, ok := s.paths[status.Path]; ok {
+			sub.delivered = status.Status
+			sub.deliveredStale = status.Stale
+		}
+	}
+
+	if frame.Data == nil {
+		return
+	}
+
+	for {
+		select {
+		case s.c <- frame:
+			s.pending = contained
+			return
+		default:
+		}
+
+		select {
+		case dropped := <-s.c:
+			for _, path := range s.pending {
+				if frame.Type == v1.FrameTypeSnapshot && slices.Contains(contained, path) {
+					if frame.RelatedTo == "" {
+						frame.RelatedTo = dropped.RelatedTo
+					}
+					continue
+				}
+				if sub, ok := s.paths[path]; ok {
+					sub.lastDelivery = time.Time{}
+					sub.delivered = ""
+					sub.deliveredStale = false
+					if sub.relatedTo == "" {
+						sub.relatedTo = dropped.RelatedTo
+					}
+				}
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+func newTestSubscriber(paths ...string) *Subscriber {
+	s := NewSubscriber()
+	s.add(paths, Options{Interval: time.Second})
+	return s
+}
+
+func statusesOf(pairs ...string) []v1.ServiceStatus {
+	var statuses []v1.ServiceStatus
+	for idx := 0; idx+1 < len(pairs); idx += 2 {
+		statuses = append(statuses, v1.ServiceStatus{Path: pairs[idx], Status: pairs[idx+1]})
+	}
+	return statuses
+}
+
+// step delivers the statuses to the subscriber the way the engine does.
+func step(s *Subscriber, statuses []v1.ServiceStatus, now time.Time) {
+	due := s.due(now)
+	if needsSnapshot(due) {
+		frame := v1.Envelope{Type: v1.FrameTypeSnapshot, RelatedTo: snapshotRelatedTo(due), Data: []byte("{}")}
+		s.deliver(frame, statusPaths(statuses), due, statuses, now)
+		return
+	}
+	transitions := s.transitions(statuses)
+	if len(transitions) == 0 {
+		s.deliver(v1.Envelope{}, nil, due, statuses, now)
+		return
+	}
+	frame := v1.Envelope{Type: v1.FrameTypeUpdate, Data: []byte("{}")}
+	s.deliver(frame, transitionPaths(transitions), due, statuses, now)
+}
+
+// snapshotPaths returns the paths which are due for a full status.
+func snapshotPaths(s *Subscriber, now time.Time) []string {
+	var paths []string
+	for _, d := range s.due(now) {
+		if d.snapshot {
+			paths = append(paths, d.path)
+		}
+	}
+	return paths
+}
+
+func TestDeliverReplacesPendingFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  []v1.ServiceStatus
+		second []v1.ServiceStatus
+		want   []string
+	}{
+		{
+			name:   "dropped update is resent as snapshot",
+			first:  statusesOf("/a", v1.ServiceStatusDown, "/b", v1.ServiceStatusOk),
+			second: statusesOf("/a", v1.ServiceStatusDown, "/b", v1.ServiceStatusDown),
+			want:   []string{"/a"},
+		},
+		{
+			name:   "dropped update of the same path",
+			first:  statusesOf("/a", v1.ServiceStatusDown, "/b", v1.ServiceStatusOk),
+			second: statusesOf("/a", v1.ServiceStatusIssues, "/b", v1.ServiceStatusOk),
+			want:   []string{"/a"},
+		},
+		{
+			name:   "consumed frame is not resent",
+			first:  statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusOk),
+			second: statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusDown),
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSubscriber("/a", "/b")
+			now := time.Now()
+
+			step(s, statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusOk), now)
+			<-s.C()
+			now = now.Add(time.Second)
+			step(s, tt.first, now)
+			now = now.Add(time.Second)
+			step(s, tt.second, now)
+
+			got := snapshotPaths(s, now)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("snapshot paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliverResendsDroppedSnapshot(t *testing.T) {
+	s := newTestSubscriber("/a", "/b")
+	now := time.Now()
+
+	step(s, statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusOk), now)
+	now = now.Add(time.Second)
+	step(s, statusesOf("/a", v1.ServiceStatusDown, "/b", v1.ServiceStatusOk), now)
+
+	want := []string{"/a", "/b"}
+	if got := snapshotPaths(s, now); !slices.Equal(got, want) {
+		t.Errorf("snapshot paths = %v, want %v", got, want)
+	}
+}
+
+func TestDeliverKeepsPathsOfNewerSnapshot(t *testing.T) {
+	s := newTestSubscriber("/a")
+	now := time.Now()
+
+	step(s, statusesOf("/a", v1.ServiceStatusOk), now)
+	now = now.Add(time.Second)
+	s.add([]string{"/b"}, Options{Interval: time.Second})
+	step(s, statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusDown), now)
+
+	if got := snapshotPaths(s, now.Add(time.Second)); len(got) != 0 {
+		t.Errorf("snapshot paths = %v, want none", got)
+	}
+	if frame := <-s.C(); frame.Type != v1.FrameTypeSnapshot {
+		t.Errorf("frame type = %s, want %s", frame.Type, v1.FrameTypeSnapshot)
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		status v1.ServiceStatus
+		want   []v1.Transition
+	}{
+		{
+			name:   "unchanged",
+			status: v1.ServiceStatus{Path: "/a", Status: v1.ServiceStatusOk},
+		},
+		{
+			name:   "status changed",
+			status: v1.ServiceStatus{Path: "/a", Status: v1.ServiceStatusDown},
+			want:   []v1.Transition{{Path: "/a", Previous: v1.ServiceStatusOk, Status: v1.ServiceStatusDown}},
+		},
+		{
+			name:   "became stale",
+			status: v1.ServiceStatus{Path: "/a", Status: v1.ServiceStatusOk, Stale: true, Age: "PT5S"},
+			want: []v1.Transition{
+				{Path: "/a", Previous: v1.ServiceStatusOk, Status: v1.ServiceStatusOk, Stale: true, Age: "PT5S"},
+			},
+		},
+		{
+			name:   "unsubscribed path",
+			status: v1.ServiceStatus{Path: "/b", Status: v1.ServiceStatusDown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSubscriber("/a")
+			now := time.Now()
+			step(s, statusesOf("/a", v1.ServiceStatusOk), now)
+
+			got := s.transitions([]v1.ServiceStatus{tt.status})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("transitions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionsRefreshedStatus(t *testing.T) {
+	s := newTestSubscriber("/a")
+	now := time.Now()
+	stale := []v1.ServiceStatus{{Path: "/a", Status: v1.ServiceStatusOk, Stale: true, Age: "PT5S"}}
+	step(s, stale, now)
+
+	want := []v1.Transition{{Path: "/a", Previous: v1.ServiceStatusOk, Status: v1.ServiceStatusOk}}
+	if got := s.transitions(statusesOf("/a", v1.ServiceStatusOk)); !slices.Equal(got, want) {
+		t.Errorf("transitions = %+v, want %+v", got, want)
+	}
+}
+
+func TestDuePerPathIntervals(t *testing.T) {
+	s := NewSubscriber()
+	s.add([]string{"/fast"}, Options{Interval: time.Second})
+	s.add([]string{"/slow"}, Options{Interval: time.Minute})
+	now := time.Now()
+
+	step(s, nil, now)
+	<-s.C()
+
+	tests := []struct {
+		name      string
+		at        time.Duration
+		wantDue   []string
+		wantDueAt time.Duration
+	}{
+		{"before any interval elapsed", 0, nil, time.Second},
+		{"fast interval elapsed", time.Second, []string{"/fast"}, time.Second},
+		{"both intervals elapsed", time.Minute, []string{"/fast", "/slow"}, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var due []string
+			for _, d := range s.due(now.Add(tt.at)) {
+				due = append(due, d.path)
+			}
+			if !slices.Equal(due, tt.wantDue) {
+				t.Errorf("due(%s) = %v, want %v", tt.at, due, tt.wantDue)
+			}
+			if next, ok := s.dueAt(); !ok || !next.Equal(now.Add(tt.wantDueAt)) {
+				t.Errorf("dueAt() = %s, want %s", next.Sub(now), tt.wantDueAt)
+			}
+		})
+	}
+}
+
+func TestHubNextDue(t *testing.T) {
+	h := newHub()
+	if _, ok := h.nextDue(); ok {
+		t.Error("nextDue() of an empty hub reported a subscriber")
+	}
+
+	now := time.Now()
+	slow, fast := NewSubscriber(), NewSubscriber()
+	slow.add([]string{"/slow"}, Options{Interval: time.Minute})
+	fast.add([]string{"/fast"}, Options{Interval: 5 * time.Second})
+	h.add(slow)
+	h.add(fast)
+	step(slow, nil, now)
+	step(fast, nil, now)
+
+	if next, ok := h.nextDue(); !ok || !next.Equal(now.Add(5*time.Second)) {
+		t.Errorf("nextDue() = %s, want the interval of the fast subscriber", next.Sub(now))
+	}
+	if due := h.due(now.Add(5 * time.Second)); len(due) != 1 || due[0] != fast {
+		t.Errorf("due() = %v, want only the fast subscriber", due)
+	}
+}
+
+func TestTransitionsOfLegacyStatuses(t *testing.T) {
+	e := &Engine{cache: make(map[string]v1.ServiceStatus)}
+	legacy, extended := NewSubscriber(), NewSubscriber()
+	legacy.add([]string{"/api"}, Options{Interval: time.Second})
+	extended.add([]string{"/api"}, Options{Interval: time.Second, ExtendedStatuses: true})
+
+	now := time.Now()
+	e.cache["/api"] = v1.ServiceStatus{Path: "/api", Status: v1.ServiceStatusOk}
+	for _, s := range []*Subscriber{legacy, extended} {
+		step(s, e.statuses(s.due(now), now), now)
+		<-s.C()
+	}
+
+	tests := []struct {
+		status       string
+		wantLegacy   []string
+		wantExtended []string
+	}{
+		{
+			status:       v1.ServiceStatusMisconfigured,
+			wantLegacy:   []string{v1.ServiceStatusOk, v1.ServiceStatusDown},
+			wantExtended: []string{v1.ServiceStatusOk, v1.ServiceStatusMisconfigured},
+		},
+		{
+			status:       v1.ServiceStatusNotFound,
+			wantLegacy:   nil,
+			wantExtended: []string{v1.ServiceStatusMisconfigured, v1.ServiceStatusNotFound},
+		},
+		{
+			status:       v1.ServiceStatusFlapping,
+			wantLegacy:   []string{v1.ServiceStatusDown, v1.ServiceStatusIssues},
+			wantExtended: []string{v1.ServiceStatusNotFound, v1.ServiceStatusFlapping},
+		},
+		{
+			status:       v1.ServiceStatusIssues,
+			wantLegacy:   nil,
+			wantExtended: []string{v1.ServiceStatusFlapping, v1.ServiceStatusIssues},
+		},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(time.Second)
+		e.cache["/api"] = v1.ServiceStatus{Path: "/api", Status: tt.status}
+
+		for s, want := range map[*Subscriber][]string{legacy: tt.wantLegacy, extended: tt.wantExtended} {
+			var got []string
+			for _, transition := range s.transitions(e.statuses(s.due(now), now)) {
+				got = append(got, transition.Previous, transition.Status)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("transitions to %s = %v, want %v", tt.status, got, want)
+			}
+
+			step(s, e.statuses(s.due(now), now), now)
+			receive(s)
+		}
+	}
+}
+
+func TestSnapshotRelatedTo(t *testing.T) {
+	s := NewSubscriber()
+	now := time.Now()
+
+	s.add([]string{"/a"}, Options{Interval: time.Second, RelatedTo: `"first"`})
+	step(s, statusesOf("/a", v1.ServiceStatusOk), now)
+	if frame := <-s.C(); frame.RelatedTo != `"first"` {
+		t.Errorf("first snapshot relatedTo = %s, want \"first\"", frame.RelatedTo)
+	}
+
+	// the snapshot of the newly subscribed path is not consumed and replaced
+	// by a newer snapshot
+	now = now.Add(time.Second)
+	s.add([]string{"/b"}, Options{Interval: time.Second, RelatedTo: "2"})
+	step(s, statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusOk), now)
+	now = now.Add(time.Second)
+	s.add([]string{"/a"}, Options{Interval: time.Second, Snapshots: true})
+	step(s, statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusOk), now)
+	if frame := <-s.C(); frame.RelatedTo != "2" {
+		t.Errorf("replacing snapshot relatedTo = %s, want 2", frame.RelatedTo)
+	}
+
+	now = now.Add(time.Second)
+	step(s, statusesOf("/a", v1.ServiceStatusOk, "/b", v1.ServiceStatusOk), now)
+	if frame := <-s.C(); frame.RelatedTo != "" {
+		t.Errorf("later snapshot relatedTo = %s, want none", frame.RelatedTo)
+	}
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+// upstreamState remembers the last observed state of an upstream.
+type upstreamState struct {
+	state    string
+	since    time.Time
+	observed time.Time
+}
+
+// trackUpstreams records the states of the upstreams and sets the point in
+// time since which every upstream is in its current state.
+// Upstreams observed for the first time are reported as being in their state
+// since their first observation.
+// The caller needs to hold the cache lock.
+func (e *Engine) trackUpstreams(upstreams []v1.UpstreamStatus, now time.Time) {
+	for idx := range upstreams {
+		upstream := &upstreams[idx]
+		key := upstream.Service + "\x00" + upstream.Url
+
+		tracked, ok := e.upstreams[key]
+		if !ok || tracked.state != upstream.State {
+			tracked = upstreamState{state: upstream.State, since: now}
+		}
+		tracked.observed = now
+		e.upstreams[key] = tracked
+
+		since := tracked.since
+		upstream.Since = &since
+	}
+}
+
+// pruneUpstreams removes the upstreams which have not been observed in the
+// last poll.
+// The caller needs to hold the cache lock.
+func (e *Engine) pruneUpstreams(now time.Time) {
+	for key, tracked := range e.upstreams {
+		if tracked.observed.Before(now) {
+			delete(e.upstreams, key)
+		}
+	}
+}
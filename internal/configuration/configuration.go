@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	_ "github.com/dr4hcu5-jan/viper-vault/remote"
 	_ "github.com/dr4hcu5-jan/viper-vault/remote/vault"
@@ -24,6 +25,9 @@ type configuration struct {
 	s           []string     // paths to the secrets to be read from the vault
 	dbRole      string
 	dbMount     string
+
+	pathsLock sync.Mutex
+	paths     map[string]PathSettings // decoded path settings, nil until first use
 }
 
 func (c *configuration) Initialize() error {
@@ -54,6 +58,9 @@ func (c *configuration) Viper() *viper.Viper {
 }
 
 func (c *configuration) Read() error {
+	// the path settings are decoded again after reading the configuration
+	defer c.resetPathSettings()
+
 	switch c.t {
 	case ConfigurationType_Local:
 		return c.readLocalConfiguration()
@@ -37,5 +37,82 @@ const (
 
 	ConfigurationKey_AuthorizationRequired = "authorization.required"
 
-	ConfigurationKey_TraefikAPIEndpoint = "traefik.api-endpoint"
+	ConfigurationKey_TraefikAPIEndpoint       = "traefik.api-endpoint"
+	ConfigurationKey_TraefikDefaultRuleSyntax = "traefik.default-rule-syntax" // used for routers without explicit syntax
+	ConfigurationKey_TraefikProviders         = "traefik.providers"           // providers whose routers are monitored
+	ConfigurationKey_TraefikPublicEntryPoints = "traefik.public-entrypoints"  // entrypoints preferred for the status
+
+	// The following keys configure the access to the traefik api.
+	// Certificates and keys may be set as pem encoded value (e.g. when using a
+	// vault) or as path to a file containing them.
+	ConfigurationKey_TraefikAPIBasePath           = "traefik.api-base-path"
+	ConfigurationKey_TraefikTimeout               = "traefik.timeout" // applied to every single api request
+	ConfigurationKey_TraefikUsername              = "traefik.username"
+	ConfigurationKey_TraefikPassword              = "traefik.password"
+	ConfigurationKey_TraefikToken                 = "traefik.token" // bearer token, preferred over basic auth
+	ConfigurationKey_TraefikCACertificate         = "traefik.tls.ca"
+	ConfigurationKey_TraefikCACertificateFile     = "traefik.tls.ca-file"
+	ConfigurationKey_TraefikClientCertificate     = "traefik.tls.certificate"
+	ConfigurationKey_TraefikClientCertificateFile = "traefik.tls.certificate-file"
+	ConfigurationKey_TraefikClientKey             = "traefik.tls.key"
+	ConfigurationKey_TraefikClientKeyFile         = "traefik.tls.key-file"
+
+	ConfigurationKey_TraefikPageSize = "traefik.page-size" // resources requested per page from list endpoints
+
+	// The circuit breaker rejects requests to the traefik api after consecutive
+	// failures. The backoff is doubled every time the api is still unavailable.
+	ConfigurationKey_TraefikBreakerFailureThreshold = "traefik.breaker.failure-threshold"
+	ConfigurationKey_TraefikBreakerInitialBackoff   = "traefik.breaker.initial-backoff"
+	ConfigurationKey_TraefikBreakerMaxBackoff       = "traefik.breaker.max-backoff"
+
+	ConfigurationKey_MonitorMinPollInterval   = "monitor.min-poll-interval"  // minimal time between two traefik polls
+	ConfigurationKey_MonitorHeartbeatInterval = "monitor.heartbeat-interval" // time between heartbeats, 0 disables them
+
+	// Clients are disconnected after sending the maximum number of invalid
+	// commands or a message exceeding the maximum size.
+	ConfigurationKey_WebsocketMaxStrikes     = "websocket.max-strikes"      // invalid commands allowed, 0 disables it
+	ConfigurationKey_WebsocketMaxMessageSize = "websocket.max-message-size" // maximum size of a received message in bytes
+
+	// The frames sent to a client are queued until they are written. If the
+	// queue is full, the slow consumer policy decides if intermediate status
+	// updates are dropped or the client is disconnected.
+	ConfigurationKey_WebsocketQueueSize    = "websocket.queue-size"    // frames queued for a single client
+	ConfigurationKey_WebsocketWriteTimeout = "websocket.write-timeout" // maximum time for writing a single frame
+	ConfigurationKey_WebsocketSlowConsumer = "websocket.slow-consumer" // policy for slow clients ("drop" or "disconnect")
+
+	ConfigurationKey_StatusPolicy     = "status.policy"      // aggregation policy for upstream states
+	ConfigurationKey_StatusMinHealthy = "status.min-healthy" // percentage of healthy upstreams for the percentage policy
+
+	// The following keys damp the transitions between the statuses of a path.
+	ConfigurationKey_StatusFailureThreshold = "status.failure-threshold" // failing observations before reporting a failure
+	ConfigurationKey_StatusSuccessThreshold = "status.success-threshold" // successful observations before reporting "ok"
+	ConfigurationKey_StatusConfirmRecheck   = "status.confirm-recheck"   // poll again to confirm pending transitions
+	ConfigurationKey_StatusFlapThreshold    = "status.flap-threshold"    // status changes marking a path as flapping
+	ConfigurationKey_StatusFlapWindow       = "status.flap-window"       // window in which status changes are counted
+
+	ConfigurationKey_PathSettings = "paths" // list of settings overridden per path
+
+	// The forwardAuth middlewares of the monitored routers are probed to
+	// detect unavailable authentication servers.
+	ConfigurationKey_MiddlewaresProbeForwardAuth   = "middlewares.probe-forward-auth"
+	ConfigurationKey_MiddlewaresForwardAuthTimeout = "middlewares.forward-auth-timeout" // timeout of a single probe
+
+	ConfigurationKey_ProbesGatewayUrl = "probes.gateway-url" // url of the gateway the probes are sent to
+	ConfigurationKey_ProbesInterval   = "probes.interval"    // default interval between two probes of a path
+	ConfigurationKey_ProbesTimeout    = "probes.timeout"     // default timeout of a single probe
+
+	// number of samples kept per source to compute the latency percentiles
+	ConfigurationKey_LatencySamples          = "latency.samples"
+	ConfigurationKey_LatencyP95Threshold     = "latency.p95-threshold"     // p95 latency above which a path is limited
+	ConfigurationKey_LatencyMeasureUpstreams = "latency.measure-upstreams" // measure the tcp connect time to upstreams
+	ConfigurationKey_LatencyConnectTimeout   = "latency.connect-timeout"   // timeout of a single upstream connect
+
+	// The following keys configure the checks of the certificates presented
+	// for the hosts of tls routers.
+	ConfigurationKey_CertificatesEnabled     = "certificates.enabled"
+	ConfigurationKey_CertificatesInterval    = "certificates.interval"     // interval between two checks of a host
+	ConfigurationKey_CertificatesTimeout     = "certificates.timeout"      // timeout of a single check
+	ConfigurationKey_CertificatesWarningDays = "certificates.warning-days" // days before the expiry a path is limited
+	// address dialed instead of the host (e.g. traefik:443)
+	ConfigurationKey_CertificatesAddress = "certificates.address"
 )
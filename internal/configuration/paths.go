@@ -0,0 +1,118 @@
+package configuration
+
+import (
+	"log/slog"
+	"time"
+)
+
+// PathSettings contains the settings which may be overridden for a single
+// monitored path.
+// Settings which are not set explicitly fall back to the global value.
+type PathSettings struct {
+	Path       string   `mapstructure:"path"`
+	Policy     string   `mapstructure:"policy"`
+	MinHealthy *float64 `mapstructure:"min-healthy"`
+
+	FailureThreshold *int           `mapstructure:"failure-threshold"`
+	SuccessThreshold *int           `mapstructure:"success-threshold"`
+	FlapThreshold    *int           `mapstructure:"flap-threshold"`
+	FlapWindow       *time.Duration `mapstructure:"flap-window"`
+
+	P95Threshold           *time.Duration `mapstructure:"p95-threshold"`
+	CertificateWarningDays *int           `mapstructure:"certificate-warning-days"`
+
+	// Maintenance reports the path with the maintenance status regardless of
+	// its actual status
+	Maintenance        bool   `mapstructure:"maintenance"`
+	MaintenanceMessage string `mapstructure:"maintenance-message"`
+
+	// Probe configures a synthetic request sent through the gateway
+	Probe *ProbeSettings `mapstructure:"probe"`
+}
+
+// ProbeSettings describe a synthetic request used to probe a path and the
+// assertions made on its response.
+type ProbeSettings struct {
+	Type string `mapstructure:"type"` // http (default), tcp, grpc or websocket
+
+	Method  string            `mapstructure:"method"`
+	Url     string            `mapstructure:"url"` // defaults to the path appended to the gateway url
+	Headers map[string]string `mapstructure:"headers"`
+	Token   string            `mapstructure:"token"` // sent as bearer token
+	Body    string            `mapstructure:"body"`
+
+	// Address is dialed by the tcp and grpc probes and defaults to the host of
+	// the gateway url
+	Address string `mapstructure:"address"`
+	TLS     bool   `mapstructure:"tls"`
+	Service string `mapstructure:"service"` // service name sent in grpc health checks
+	Echo    string `mapstructure:"echo"`    // message which needs to be echoed by a websocket server
+
+	Interval time.Duration `mapstructure:"interval"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+
+	Expect ProbeExpectations `mapstructure:"expect"`
+}
+
+// ProbeExpectations contain the assertions made on the response of a probe.
+type ProbeExpectations struct {
+	Status     []int             `mapstructure:"status"` // defaults to any 2xx status
+	JSON       []JSONExpectation `mapstructure:"json"`
+	Body       string            `mapstructure:"body"`   // regular expression matched against the body
+	Banner     string            `mapstructure:"banner"` // regular expression matched against the tcp banner
+	MaxLatency time.Duration     `mapstructure:"max-latency"`
+}
+
+// JSONExpectation asserts that the value at the JSON path (e.g. $.data[0].id)
+// of the response body equals the value.
+type JSONExpectation struct {
+	Path  string `mapstructure:"path"`
+	Value string `mapstructure:"value"`
+}
+
+// PathSettings returns the settings configured for the supplied path.
+// If the path has no settings configured, an empty [PathSettings] is returned.
+func (c *configuration) PathSettings(path string) PathSettings {
+	if settings, ok := c.pathSettings()[path]; ok {
+		return settings
+	}
+	return PathSettings{Path: path}
+}
+
+// pathSettings returns the settings of all configured paths. The settings are
+// only decoded once and reused until the configuration is read again.
+// If the settings cannot be decoded, the error is logged and no path uses
+// overridden settings.
+func (c *configuration) pathSettings() map[string]PathSettings {
+	c.pathsLock.Lock()
+	defer c.pathsLock.Unlock()
+
+	if c.paths != nil {
+		return c.paths
+	}
+
+	c.paths = make(map[string]PathSettings)
+
+	var settings []PathSettings
+	if err := c.i.UnmarshalKey(ConfigurationKey_PathSettings, &settings); err != nil {
+		slog.Error("unable to decode the path settings, no per-path settings are applied", "error", err)
+		return c.paths
+	}
+
+	for _, s := range settings {
+		if _, duplicate := c.paths[s.Path]; duplicate {
+			slog.Warn("path configured multiple times, only the first settings are applied", "path", s.Path)
+			continue
+		}
+		c.paths[s.Path] = s
+	}
+	return c.paths
+}
+
+// resetPathSettings discards the decoded path settings, so that they are
+// decoded again on their next use.
+func (c *configuration) resetPathSettings() {
+	c.pathsLock.Lock()
+	defer c.pathsLock.Unlock()
+	c.paths = nil
+}
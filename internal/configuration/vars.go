@@ -1,5 +1,7 @@
 package configuration
 
+import "time"
+
 // This file contains the sensible default values that are used in the
 // configuration as well as other variables used for the configuration and the
 // reading of it.
@@ -27,6 +29,33 @@ var environmentVariables = map[string][]string{
 	ConfigurationKey_AuthorizationRequired: {"AUTH_REQUIRED", "AUTHORIZATION_REQUIRED"},
 	ConfigurationKey_OidcAuthority:         {"OIDC_AUTHORITY", "OIDC_ISSUER"},
 	ConfigurationKey_TraefikAPIEndpoint:    {"TRAEFIK_API_URL"},
+
+	ConfigurationKey_TraefikDefaultRuleSyntax: {"TRAEFIK_DEFAULT_RULE_SYNTAX"},
+	ConfigurationKey_TraefikProviders:         {"TRAEFIK_PROVIDERS"},
+	ConfigurationKey_TraefikPublicEntryPoints: {"TRAEFIK_PUBLIC_ENTRYPOINTS"},
+	ConfigurationKey_TraefikAPIBasePath:       {"TRAEFIK_API_BASE_PATH"},
+	ConfigurationKey_TraefikTimeout:           {"TRAEFIK_API_TIMEOUT"},
+	ConfigurationKey_TraefikUsername:          {"TRAEFIK_API_USER", "TRAEFIK_API_USERNAME"},
+	ConfigurationKey_TraefikPassword:          {"TRAEFIK_API_PASSWORD"},
+	ConfigurationKey_TraefikToken:             {"TRAEFIK_API_TOKEN"},
+	ConfigurationKey_TraefikCACertificateFile: {"TRAEFIK_API_CA_FILE"},
+
+	ConfigurationKey_TraefikClientCertificateFile: {"TRAEFIK_API_CERTIFICATE_FILE"},
+	ConfigurationKey_TraefikClientKeyFile:         {"TRAEFIK_API_KEY_FILE"},
+	ConfigurationKey_TraefikPageSize:              {"TRAEFIK_API_PAGE_SIZE"},
+	ConfigurationKey_MonitorMinPollInterval:       {"MONITOR_MIN_POLL_INTERVAL"},
+	ConfigurationKey_StatusPolicy:                 {"STATUS_POLICY"},
+	ConfigurationKey_StatusMinHealthy:             {"STATUS_MIN_HEALTHY"},
+
+	ConfigurationKey_TraefikBreakerFailureThreshold: {"TRAEFIK_BREAKER_FAILURE_THRESHOLD"},
+	ConfigurationKey_ProbesGatewayUrl:               {"PROBES_GATEWAY_URL", "GATEWAY_URL"},
+	ConfigurationKey_CertificatesAddress:            {"CERTIFICATES_ADDRESS"},
+	ConfigurationKey_MonitorHeartbeatInterval:       {"MONITOR_HEARTBEAT_INTERVAL"},
+	ConfigurationKey_WebsocketMaxStrikes:            {"WEBSOCKET_MAX_STRIKES"},
+	ConfigurationKey_WebsocketMaxMessageSize:        {"WEBSOCKET_MAX_MESSAGE_SIZE"},
+	ConfigurationKey_WebsocketSlowConsumer:          {"WEBSOCKET_SLOW_CONSUMER"},
+	ConfigurationKey_WebsocketQueueSize:             {"WEBSOCKET_QUEUE_SIZE"},
+	ConfigurationKey_WebsocketWriteTimeout:          {"WEBSOCKET_WRITE_TIMEOUT"},
 }
 
 var defaults = map[string]any{
@@ -34,4 +63,50 @@ var defaults = map[string]any{
 	ConfigurationKey_DatabaseSSLMode: "disable",
 	ConfigurationKey_DatabaseName:    "wisdom",
 	ConfigurationKey_HttpPort:        8000, //nolint:mnd
+
+	ConfigurationKey_TraefikDefaultRuleSyntax: "v3",
+	ConfigurationKey_TraefikProviders:         []string{}, // an empty list allows all providers
+	ConfigurationKey_TraefikPublicEntryPoints: []string{"web", "websecure"},
+	ConfigurationKey_TraefikAPIBasePath:       "/api",
+	ConfigurationKey_TraefikTimeout:           10 * time.Second, //nolint:mnd
+	ConfigurationKey_TraefikPageSize:          100,              //nolint:mnd
+
+	ConfigurationKey_TraefikBreakerFailureThreshold: 3,               //nolint:mnd
+	ConfigurationKey_TraefikBreakerInitialBackoff:   5 * time.Second, //nolint:mnd
+	ConfigurationKey_TraefikBreakerMaxBackoff:       5 * time.Minute, //nolint:mnd
+
+	ConfigurationKey_MonitorMinPollInterval:   5 * time.Second,  //nolint:mnd
+	ConfigurationKey_MonitorHeartbeatInterval: 30 * time.Second, //nolint:mnd
+
+	ConfigurationKey_WebsocketMaxStrikes:     5,         //nolint:mnd
+	ConfigurationKey_WebsocketMaxMessageSize: 64 * 1024, //nolint:mnd
+
+	ConfigurationKey_WebsocketQueueSize:    16,               //nolint:mnd
+	ConfigurationKey_WebsocketWriteTimeout: 10 * time.Second, //nolint:mnd
+	ConfigurationKey_WebsocketSlowConsumer: "drop",
+
+	ConfigurationKey_StatusPolicy:     "any",
+	ConfigurationKey_StatusMinHealthy: 50, //nolint:mnd
+
+	ConfigurationKey_StatusFailureThreshold: 1,
+	ConfigurationKey_StatusSuccessThreshold: 1,
+	ConfigurationKey_StatusConfirmRecheck:   false,
+	ConfigurationKey_StatusFlapThreshold:    0,                // disables the flap detection
+	ConfigurationKey_StatusFlapWindow:       10 * time.Minute, //nolint:mnd
+
+	ConfigurationKey_MiddlewaresProbeForwardAuth:   false,
+	ConfigurationKey_MiddlewaresForwardAuthTimeout: 5 * time.Second, //nolint:mnd
+
+	ConfigurationKey_ProbesInterval: 30 * time.Second, //nolint:mnd
+	ConfigurationKey_ProbesTimeout:  5 * time.Second,  //nolint:mnd
+
+	ConfigurationKey_LatencySamples:          60, //nolint:mnd
+	ConfigurationKey_LatencyP95Threshold:     0,  // disables the latency based degradation
+	ConfigurationKey_LatencyMeasureUpstreams: true,
+	ConfigurationKey_LatencyConnectTimeout:   2 * time.Second, //nolint:mnd
+
+	ConfigurationKey_CertificatesEnabled:     true,
+	ConfigurationKey_CertificatesInterval:    time.Hour,
+	ConfigurationKey_CertificatesTimeout:     10 * time.Second, //nolint:mnd
+	ConfigurationKey_CertificatesWarningDays: 14,               //nolint:mnd
 }
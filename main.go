@@ -14,9 +14,12 @@ import (
 
 	flag "github.com/spf13/pflag"
 
+	"microservice/engine"
 	"microservice/healthchecks"
 	"microservice/internal/configuration"
+	"microservice/probe"
 	"microservice/router"
+	"microservice/traefik"
 )
 
 var headerReadTimeout = 10 * time.Second
@@ -40,6 +43,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	traefikClient, err := traefik.NewClientFromConfiguration()
+	if err != nil {
+		slog.Error("unable to create traefik api client", "error", err)
+		os.Exit(1)
+	}
+	traefik.DefaultClient = traefikClient
+
 	if runHc != nil && *runHc {
 		hcStart := time.Now()
 		ctx := context.WithValue(context.Background(), "plain", true) //nolint: staticcheck
@@ -59,6 +69,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// start the status engine shared by all websocket connections
+	engineCtx, stopEngine := context.WithCancel(context.Background())
+	defer stopEngine()
+	probeRunner := probe.NewRunner()
+	engine.Default = engine.New(traefikClient, probeRunner)
+	go engine.Default.Run(engineCtx)
+	go probeRunner.Run(engineCtx, engine.Default.Hub().Paths)
+
 	c := configuration.Default.Viper()
 
 	// create a http server to handle the requests
@@ -0,0 +1,85 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"math"
+	"net"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+const (
+	defaultTLSPort = "443"
+	hoursPerDay    = 24
+)
+
+// checkCertificate connects to the host and inspects the presented
+// certificate chain.
+// The chain is read even if it cannot be verified to report the details of
+// invalid certificates.
+func checkCertificate(ctx context.Context, host string) v1.CertificateStatus {
+	now := time.Now()
+	status := v1.CertificateStatus{Host: host, CheckedAt: &now}
+
+	c := config.Default.Viper()
+	address := c.GetString(config.ConfigurationKey_CertificatesAddress)
+	if address == "" {
+		address = net.JoinHostPort(host, defaultTLSPort)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.GetDuration(config.ConfigurationKey_CertificatesTimeout))
+	defer cancel()
+
+	dialer := tls.Dialer{Config: &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true, //nolint:gosec // the chain is verified below
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer conn.Close()
+
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		status.Error = "connection does not use tls"
+		return status
+	}
+
+	chain := tlsConn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		status.Error = "no certificate presented"
+		return status
+	}
+
+	leaf := chain[0]
+	status.Subject = leaf.Subject.String()
+	status.Issuer = leaf.Issuer.String()
+	status.SANs = leaf.DNSNames
+	status.Covered = leaf.VerifyHostname(host) == nil
+
+	notAfter := leaf.NotAfter
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+		if certificate.NotAfter.Before(notAfter) {
+			notAfter = certificate.NotAfter
+		}
+	}
+	status.NotAfter = &notAfter
+	status.DaysUntilExpiry = int(math.Floor(notAfter.Sub(now).Hours() / hoursPerDay))
+
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates})
+	status.Trusted = err == nil
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	return status
+}
@@ -0,0 +1,166 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// grpcHealthCheckMethod is the method of the standard gRPC health checking
+// protocol (grpc.health.v1).
+const grpcHealthCheckMethod = "/grpc.health.v1.Health/Check"
+
+// grpcFrameHeaderSize is the size of the header preceding every gRPC message
+// (compression flag and message length).
+const grpcFrameHeaderSize = 5
+
+// The serving statuses defined by the gRPC health checking protocol.
+var grpcServingStatuses = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN", //nolint:mnd
+}
+
+const grpcServing = 1
+
+var grpcClient = &http.Client{Transport: newGRPCTransport()}
+
+// newGRPCTransport creates a transport only using HTTP/2 as required by gRPC.
+// Plaintext connections use HTTP/2 with prior knowledge.
+func newGRPCTransport() *http.Transport {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &http.Transport{
+		Protocols:       protocols,
+		TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+}
+
+// grpcProbe calls the Check method of the standard gRPC health checking
+// protocol.
+// The probe fails unless the server reports the configured service as
+// serving.
+type grpcProbe struct{}
+
+func (grpcProbe) Run(ctx context.Context, path string, settings config.ProbeSettings) v1.ProbeResult {
+	result := newResult()
+
+	address, err := probeAddress(path, settings)
+	if err != nil {
+		return result.fail(err)
+	}
+
+	scheme := "http"
+	if settings.TLS {
+		scheme = "https"
+	}
+
+	var message []byte
+	if settings.Service != "" {
+		message = protowire.AppendTag(message, 1, protowire.BytesType)
+		message = protowire.AppendString(message, settings.Service)
+	}
+	frame := make([]byte, grpcFrameHeaderSize, grpcFrameHeaderSize+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message))) //nolint:gosec
+	frame = append(frame, message...)
+
+	uri := scheme + "://" + address + grpcHealthCheckMethod
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(frame))
+	if err != nil {
+		return result.fail(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	for name, value := range settings.Headers {
+		req.Header.Set(name, value)
+	}
+	if settings.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+settings.Token)
+	}
+
+	res, err := grpcClient.Do(req)
+	if err != nil {
+		return result.fail(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	result.measure()
+	result.StatusCode = res.StatusCode
+	if err != nil {
+		return result.fail(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return result.fail(fmt.Errorf("unexpected http status code %d", res.StatusCode))
+	}
+
+	// responses without a message carry the grpc status in their headers
+	grpcStatus, grpcMessage := res.Trailer.Get("Grpc-Status"), res.Trailer.Get("Grpc-Message")
+	if grpcStatus == "" {
+		grpcStatus, grpcMessage = res.Header.Get("Grpc-Status"), res.Header.Get("Grpc-Message")
+	}
+	if grpcStatus != "0" {
+		return result.fail(fmt.Errorf("health check failed with grpc status %s: %s", grpcStatus, grpcMessage))
+	}
+
+	servingStatus, err := parseServingStatus(body)
+	if err != nil {
+		return result.fail(err)
+	}
+	if servingStatus != grpcServing {
+		return result.fail(fmt.Errorf("service reported as %s", grpcServingStatuses[servingStatus]))
+	}
+
+	return result.checkLatency(settings.Expect.MaxLatency)
+}
+
+// parseServingStatus reads the serving status from a framed
+// grpc.health.v1.HealthCheckResponse message.
+func parseServingStatus(body []byte) (uint64, error) {
+	if len(body) < grpcFrameHeaderSize {
+		return 0, errors.New("health check response does not contain a message")
+	}
+	if body[0] != 0 {
+		return 0, errors.New("compressed health check responses are not supported")
+	}
+
+	message := body[grpcFrameHeaderSize:]
+	for len(message) > 0 {
+		number, wireType, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		message = message[n:]
+
+		if number == 1 && wireType == protowire.VarintType {
+			status, n := protowire.ConsumeVarint(message)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return status, nil
+		}
+
+		n = protowire.ConsumeFieldValue(number, wireType, message)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		message = message[n:]
+	}
+
+	// the default value of the status field is not encoded
+	return 0, nil
+}
@@ -0,0 +1,54 @@
+package probe
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// frame prefixes the message with the gRPC frame header.
+func frame(compressed byte, message []byte) []byte {
+	header := make([]byte, grpcFrameHeaderSize)
+	header[0] = compressed
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message))) //nolint:gosec
+	return append(header, message...)
+}
+
+func TestParseServingStatus(t *testing.T) {
+	status := func(value uint64) []byte {
+		message := protowire.AppendTag(nil, 1, protowire.VarintType)
+		return protowire.AppendVarint(message, value)
+	}
+	unknownField := protowire.AppendString(protowire.AppendTag(nil, 2, protowire.BytesType), "ignored")
+
+	tests := []struct {
+		name    string
+		body    []byte
+		want    uint64
+		wantErr bool
+	}{
+		{name: "serving", body: frame(0, status(grpcServing)), want: grpcServing},
+		{name: "not serving", body: frame(0, status(2)), want: 2},
+		{name: "service unknown", body: frame(0, status(3)), want: 3},
+		{name: "default status is not encoded", body: frame(0, nil), want: 0},
+		{name: "unknown fields are skipped", body: frame(0, append(unknownField, status(grpcServing)...)), want: grpcServing},
+		{name: "missing frame header", body: []byte{0, 0}, wantErr: true},
+		{name: "compressed message", body: frame(1, status(grpcServing)), wantErr: true},
+		{name: "truncated varint", body: frame(0, []byte{0x08, 0x80}), wantErr: true},
+		{name: "invalid tag", body: frame(0, []byte{0x80}), wantErr: true},
+		{name: "truncated unknown field", body: frame(0, []byte{0x12, 0x05, 'a'}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseServingStatus(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseServingStatus() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseServingStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// maxBodySize limits the part of the response body the assertions are made on.
+const maxBodySize = 1 << 20
+
+var httpClient = &http.Client{
+	// redirects are part of the response the assertions are made on (e.g. a
+	// redirect to a login page instead of the expected response)
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// httpProbe sends the configured request and asserts the expectations on the
+// response.
+// Failed assertions result in a down status. If only the latency exceeds the
+// maximum, the status is limited.
+type httpProbe struct{}
+
+func (httpProbe) Run(ctx context.Context, path string, settings config.ProbeSettings) v1.ProbeResult {
+	result := newResult()
+
+	uri, err := probeUrl(path, settings)
+	if err != nil {
+		return result.fail(err)
+	}
+
+	method := settings.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri.String(), strings.NewReader(settings.Body))
+	if err != nil {
+		return result.fail(err)
+	}
+	for name, value := range settings.Headers {
+		req.Header.Set(name, value)
+	}
+	if settings.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+settings.Token)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return result.fail(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	result.measure()
+	result.StatusCode = res.StatusCode
+	if err != nil {
+		return result.fail(err)
+	}
+
+	for _, err := range assertResponse(res.StatusCode, body, settings.Expect) {
+		result.fail(err)
+	}
+
+	return result.checkLatency(settings.Expect.MaxLatency)
+}
+
+// assertResponse checks the status code and body of the response against the
+// expectations and returns the failed assertions.
+func assertResponse(statusCode int, body []byte, expect config.ProbeExpectations) []error {
+	var errs []error
+
+	switch {
+	case len(expect.Status) > 0 && !slices.Contains(expect.Status, statusCode):
+		errs = append(errs, fmt.Errorf("unexpected status code %d, expected one of %v", statusCode, expect.Status))
+	case len(expect.Status) == 0 && (statusCode < 200 || statusCode > 299):
+		errs = append(errs, fmt.Errorf("unexpected status code %d, expected a 2xx status code", statusCode))
+	}
+
+	if err := matchPattern("body", expect.Body, body); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(expect.JSON) == 0 {
+		return errs
+	}
+
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return append(errs, fmt.Errorf("body is not valid json: %w", err))
+	}
+
+	for _, expectation := range expect.JSON {
+		value, err := lookupJSONPath(document, expectation.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if actual := fmt.Sprint(value); actual != expectation.Value {
+			errs = append(errs, fmt.Errorf("value at %s is %q, expected %q", expectation.Path, actual, expectation.Value))
+		}
+	}
+
+	return errs
+}
+
+// matchPattern checks if the data matches the regular expression.
+// An empty expression matches all data.
+func matchPattern(name, expression string, data []byte) error {
+	if expression == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(expression)
+	if err != nil {
+		return fmt.Errorf("invalid %s expectation: %w", name, err)
+	}
+	if !pattern.Match(data) {
+		return fmt.Errorf("%s does not match %s", name, expression)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errInvalidJSONPath = errors.New("invalid json path")
+
+// lookupJSONPath resolves a simple JSON path in the decoded JSON value.
+// The path needs to start with $ followed by any number of member accesses
+// (.name) and array indices ([0]).
+func lookupJSONPath(value any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not start with $", errInvalidJSONPath, path)
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			rest = rest[end+1:]
+
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("value at %s is not an object", strings.TrimSuffix(path, rest))
+			}
+			if value, ok = object[name]; !ok {
+				return nil, fmt.Errorf("value at %s does not exist", strings.TrimSuffix(path, rest))
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("%w: unterminated index in %s", errInvalidJSONPath, path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid index in %s", errInvalidJSONPath, path)
+			}
+			rest = rest[end+1:]
+
+			array, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("value at %s is not an array", strings.TrimSuffix(path, rest))
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("value at %s does not exist", strings.TrimSuffix(path, rest))
+			}
+			value = array[index]
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q in %s", errInvalidJSONPath, rest[0], path)
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,59 @@
+package probe
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var document any
+	err := json.Unmarshal([]byte(`{
+		"status": "ok",
+		"data": [{"id": 1, "tags": ["a", "b"]}, {"id": 2}],
+		"nested": {"deep": {"value": true}},
+		"empty": null
+	}`), &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    any
+		wantErr bool
+		invalid bool // the path itself is invalid
+	}{
+		{path: "$", want: document},
+		{path: "$.status", want: "ok"},
+		{path: "$.data[1].id", want: float64(2)},
+		{path: "$.data[0].tags[1]", want: "b"},
+		{path: "$.nested.deep.value", want: true},
+		{path: "$.empty", want: nil},
+		{path: "$.missing", wantErr: true},
+		{path: "$.data[2]", wantErr: true},
+		{path: "$.data[-1]", wantErr: true},
+		{path: "$.status.length", wantErr: true},
+		{path: "$.nested[0]", wantErr: true},
+		{path: "status", wantErr: true, invalid: true},
+		{path: "$.data[x]", wantErr: true, invalid: true},
+		{path: "$.data[0", wantErr: true, invalid: true},
+		{path: "$status", wantErr: true, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := lookupJSONPath(document, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookupJSONPath() error = %v, want error %t", err, tt.wantErr)
+			}
+			if errors.Is(err, errInvalidJSONPath) != tt.invalid {
+				t.Errorf("lookupJSONPath() error = %v, want invalid path %t", err, tt.invalid)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupJSONPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// The supported probe types.
+const (
+	TypeHTTP      = "http"
+	TypeTCP       = "tcp"
+	TypeGRPC      = "grpc"
+	TypeWebSocket = "websocket"
+)
+
+// Probe checks a single path using the configured settings.
+// Every probe type reports its outcome as [v1.ProbeResult], allowing the
+// results to be combined with the statuses derived from Traefik.
+type Probe interface {
+	Run(ctx context.Context, path string, settings config.ProbeSettings) v1.ProbeResult
+}
+
+var probes = map[string]Probe{
+	TypeHTTP:      httpProbe{},
+	TypeTCP:       tcpProbe{},
+	TypeGRPC:      grpcProbe{},
+	TypeWebSocket: webSocketProbe{},
+}
+
+// run executes the probe of the configured type.
+func run(ctx context.Context, path string, settings config.ProbeSettings) v1.ProbeResult {
+	probeType := settings.Type
+	if probeType == "" {
+		probeType = TypeHTTP
+	}
+
+	probe, ok := probes[probeType]
+	if !ok {
+		result := newResult()
+		return result.fail(fmt.Errorf("unsupported probe type %q", probeType))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+	defer cancel()
+	return probe.Run(ctx, path, settings)
+}
+
+// result wraps the result of a probe to collect its errors.
+type result struct {
+	v1.ProbeResult
+	start time.Time
+}
+
+func newResult() *result {
+	now := time.Now()
+	return &result{
+		ProbeResult: v1.ProbeResult{Status: v1.ServiceStatusOk, CheckedAt: now},
+		start:       now,
+	}
+}
+
+// fail marks the probe as failed and records the error.
+// If the latency has not been measured yet, the time until the failure is
+// used, so that timeouts are reflected in the latency.
+func (r *result) fail(err error) v1.ProbeResult {
+	if r.LatencyMs == 0 {
+		r.measure()
+	}
+	r.Status = v1.ServiceStatusDown
+	r.Errors = append(r.Errors, err.Error())
+	return r.ProbeResult
+}
+
+// measure records the time since the start of the probe as its latency.
+func (r *result) measure() {
+	r.LatencyMs = time.Since(r.start).Milliseconds()
+}
+
+// checkLatency degrades the probe if the latency exceeds the maximum.
+func (r *result) checkLatency(maxLatency time.Duration) v1.ProbeResult {
+	if maxLatency > 0 && time.Duration(r.LatencyMs)*time.Millisecond > maxLatency {
+		r.Status = v1.WorseStatus(r.Status, v1.ServiceStatusIssues)
+		r.Errors = append(r.Errors, fmt.Sprintf("latency of %dms exceeds the maximum of %s", r.LatencyMs, maxLatency))
+	}
+	return r.ProbeResult
+}
+
+// gatewayUrl returns the configured url of the gateway.
+func gatewayUrl(path string) (*url.URL, error) {
+	gateway := config.Default.Viper().GetString(config.ConfigurationKey_ProbesGatewayUrl)
+	base, err := url.Parse(gateway)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("no valid gateway url configured for probing %s", path)
+	}
+	return base, nil
+}
+
+// probeUrl returns the url the probe of the path is sent to.
+// Paths prefixed with a host are requested using the scheme of the gateway
+// url.
+func probeUrl(path string, settings config.ProbeSettings) (*url.URL, error) {
+	if settings.Url != "" {
+		return url.Parse(settings.Url)
+	}
+
+	base, err := gatewayUrl(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) > 0 && path[0] == '/' {
+		return base.JoinPath(path), nil
+	}
+	return url.Parse(base.Scheme + "://" + path)
+}
+
+// probeAddress returns the address dialed by connection based probes.
+// If no address is configured, the host of the gateway url is used.
+func probeAddress(path string, settings config.ProbeSettings) (string, error) {
+	if settings.Address != "" {
+		return settings.Address, nil
+	}
+
+	base, err := gatewayUrl(path)
+	if err != nil {
+		return "", err
+	}
+
+	return hostPort(base), nil
+}
+
+// hostPort returns the address of the url's host, using the default port of
+// the scheme if the url does not contain a port.
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
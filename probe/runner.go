@@ -0,0 +1,204 @@
+package probe
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// checkInterval determines how often the runner checks for due probes.
+const checkInterval = time.Second
+
+// failedCertificateInterval is the maximum time until a certificate which
+// could not be read is checked again.
+const failedCertificateInterval = time.Minute
+
+// Runner periodically probes the paths which have a probe configured and
+// keeps the latest result of every path.
+// Additionally, it checks the certificates of the watched hosts.
+type Runner struct {
+	lock    sync.RWMutex
+	results map[string]v1.ProbeResult
+	running map[string]struct{}
+
+	hosts        map[string]struct{}
+	certificates map[string]v1.CertificateStatus
+	checking     map[string]struct{}
+}
+
+// NewRunner creates a new runner without any results.
+// The runner needs to be started using [Runner.Run] before it probes any
+// path.
+func NewRunner() *Runner {
+	return &Runner{
+		results: make(map[string]v1.ProbeResult),
+		running: make(map[string]struct{}),
+
+		hosts:        make(map[string]struct{}),
+		certificates: make(map[string]v1.CertificateStatus),
+		checking:     make(map[string]struct{}),
+	}
+}
+
+// WatchCertificates replaces the hosts whose certificates are checked.
+// Results of hosts which are no longer watched are discarded.
+func (r *Runner) WatchCertificates(hosts []string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.hosts = make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		r.hosts[host] = struct{}{}
+	}
+	for host := range r.certificates {
+		if _, ok := r.hosts[host]; !ok {
+			delete(r.certificates, host)
+		}
+	}
+}
+
+// Certificate returns the latest certificate check of the supplied host.
+func (r *Runner) Certificate(host string) (v1.CertificateStatus, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	status, ok := r.certificates[host]
+	return status, ok
+}
+
+// Result returns the latest probe result of the supplied path.
+func (r *Runner) Result(path string) (v1.ProbeResult, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	result, ok := r.results[path]
+	return result, ok
+}
+
+// Run probes the paths returned by the supplied function until the context is
+// canceled.
+// Results of paths which are no longer returned are discarded.
+func (r *Runner) Run(ctx context.Context, paths func() []string) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := make(map[string]struct{})
+		for _, path := range paths() {
+			current[path] = struct{}{}
+
+			settings, ok := settingsFor(path)
+			if !ok || !r.start(path, settings.Interval) {
+				continue
+			}
+
+			go func() {
+				result := run(ctx, path, settings)
+				r.finish(path, result)
+			}()
+		}
+
+		r.lock.Lock()
+		for path := range r.results {
+			if _, ok := current[path]; !ok {
+				delete(r.results, path)
+			}
+		}
+		r.lock.Unlock()
+
+		r.checkCertificates(ctx)
+	}
+}
+
+// checkCertificates checks the certificates of the watched hosts whose last
+// check is older than the configured interval. Failed checks are retried
+// after the [failedCertificateInterval] at the latest.
+func (r *Runner) checkCertificates(ctx context.Context) {
+	c := config.Default.Viper()
+	if !c.GetBool(config.ConfigurationKey_CertificatesEnabled) {
+		return
+	}
+	interval := c.GetDuration(config.ConfigurationKey_CertificatesInterval)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for host := range r.hosts {
+		if _, checking := r.checking[host]; checking {
+			continue
+		}
+		if status, ok := r.certificates[host]; ok && time.Since(*status.CheckedAt) < certificateInterval(status, interval) {
+			continue
+		}
+
+		r.checking[host] = struct{}{}
+		go func() {
+			status := checkCertificate(ctx, host)
+
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			delete(r.checking, host)
+			if _, watched := r.hosts[host]; watched {
+				r.certificates[host] = status
+			}
+		}()
+	}
+}
+
+// certificateInterval returns the time until the certificate is checked again.
+func certificateInterval(status v1.CertificateStatus, interval time.Duration) time.Duration {
+	if status.NotAfter == nil {
+		return min(interval, failedCertificateInterval)
+	}
+	return interval
+}
+
+// start marks the path as being probed if its last probe is older than the
+// interval and no probe is currently running.
+func (r *Runner) start(path string, interval time.Duration) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, running := r.running[path]; running {
+		return false
+	}
+	if result, ok := r.results[path]; ok && time.Since(result.CheckedAt) < interval {
+		return false
+	}
+
+	r.running[path] = struct{}{}
+	return true
+}
+
+func (r *Runner) finish(path string, result v1.ProbeResult) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.running, path)
+	r.results[path] = result
+}
+
+// settingsFor returns the probe configured for the path with the global
+// defaults applied.
+func settingsFor(path string) (config.ProbeSettings, bool) {
+	probe := config.Default.PathSettings(path).Probe
+	if probe == nil {
+		return config.ProbeSettings{}, false
+	}
+
+	settings := *probe
+	c := config.Default.Viper()
+	if settings.Interval <= 0 {
+		settings.Interval = c.GetDuration(config.ConfigurationKey_ProbesInterval)
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = c.GetDuration(config.ConfigurationKey_ProbesTimeout)
+	}
+	return settings, true
+}
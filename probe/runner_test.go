@@ -0,0 +1,31 @@
+package probe
+
+import (
+	"testing"
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+func TestCertificateInterval(t *testing.T) {
+	notAfter := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		status   v1.CertificateStatus
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"checked certificate", v1.CertificateStatus{NotAfter: &notAfter}, time.Hour, time.Hour},
+		{"failed check", v1.CertificateStatus{Error: "timeout"}, time.Hour, failedCertificateInterval},
+		{"failed check with shorter interval", v1.CertificateStatus{Error: "timeout"}, time.Second, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certificateInterval(tt.status, tt.interval); got != tt.want {
+				t.Errorf("certificateInterval() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// maxBannerSize limits the part of the banner the expectation is matched on.
+const maxBannerSize = 1024
+
+// tcpProbe dials the configured address and optionally checks the banner the
+// server sends after the connection has been established.
+type tcpProbe struct{}
+
+func (tcpProbe) Run(ctx context.Context, path string, settings config.ProbeSettings) v1.ProbeResult {
+	result := newResult()
+
+	address, err := probeAddress(path, settings)
+	if err != nil {
+		return result.fail(err)
+	}
+
+	conn, err := dial(ctx, address, settings.TLS)
+	if err != nil {
+		return result.fail(err)
+	}
+	defer conn.Close()
+
+	if settings.Expect.Banner == "" {
+		result.measure()
+		return result.checkLatency(settings.Expect.MaxLatency)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+	}
+
+	banner := make([]byte, maxBannerSize)
+	n, err := conn.Read(banner)
+	result.measure()
+	if err != nil {
+		return result.fail(fmt.Errorf("unable to read banner: %w", err))
+	}
+
+	if err := matchPattern("banner", settings.Expect.Banner, banner[:n]); err != nil {
+		return result.fail(err)
+	}
+	return result.checkLatency(settings.Expect.MaxLatency)
+}
+
+// dial connects to the address, optionally using TLS.
+func dial(ctx context.Context, address string, useTLS bool) (net.Conn, error) {
+	if !useTLS {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid probe address: %w", err)
+	}
+
+	dialer := tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// Connect measures the time needed to establish a tcp connection to the
+// upstream.
+// The upstream may either be an url (e.g. http://backend:8080) or an address
+// (e.g. backend:5432).
+func Connect(ctx context.Context, upstream string) (time.Duration, error) {
+	address := upstream
+	if parsed, err := url.Parse(upstream); err == nil && parsed.Host != "" {
+		address = hostPort(parsed)
+	}
+
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(start)
+	_ = conn.Close()
+	return elapsed, nil
+}
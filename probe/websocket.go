@@ -0,0 +1,75 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// webSocketProbe performs the websocket upgrade handshake and optionally sends
+// a message which needs to be echoed by the server.
+type webSocketProbe struct{}
+
+func (webSocketProbe) Run(ctx context.Context, path string, settings config.ProbeSettings) v1.ProbeResult {
+	result := newResult()
+
+	uri, err := probeUrl(path, settings)
+	if err != nil {
+		return result.fail(err)
+	}
+	switch uri.Scheme {
+	case "http":
+		uri.Scheme = "ws"
+	case "https":
+		uri.Scheme = "wss"
+	}
+
+	header := make(http.Header)
+	for name, value := range settings.Headers {
+		header.Set(name, value)
+	}
+	if settings.Token != "" {
+		header.Set("Authorization", "Bearer "+settings.Token)
+	}
+
+	conn, res, err := websocket.DefaultDialer.DialContext(ctx, uri.String(), header)
+	if res != nil {
+		result.StatusCode = res.StatusCode
+		_ = res.Body.Close()
+	}
+	if err != nil {
+		return result.fail(fmt.Errorf("websocket handshake failed: %w", err))
+	}
+	defer conn.Close()
+
+	if settings.Echo != "" {
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetWriteDeadline(deadline)
+			_ = conn.SetReadDeadline(deadline)
+		}
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(settings.Echo)); err != nil {
+			return result.fail(fmt.Errorf("unable to send echo message: %w", err))
+		}
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return result.fail(fmt.Errorf("unable to read echo message: %w", err))
+		}
+		if string(message) != settings.Echo {
+			return result.fail(fmt.Errorf("server responded with %q instead of the echo message", message))
+		}
+	}
+	result.measure()
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+
+	return result.checkLatency(settings.Expect.MaxLatency)
+}
@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// The policies applied to clients which do not consume the frames as fast as
+// they are produced.
+const (
+	SlowConsumerDrop       = "drop"       // drop intermediate status updates
+	SlowConsumerDisconnect = "disconnect" // close the connection
+)
+
+// outgoing is an entry of the outbound queue. Entries with a close code close
+// the connection after all frames queued before have been written.
+type outgoing struct {
+	frame     v1.Envelope
+	closeCode int
+	closeText string
+}
+
+// connection manages the lifecycle of a websocket connection.
+// The frames are written by a dedicated write pump reading from a bounded
+// queue, while the read pump hands the received messages to the handler.
+// Both pumps stop as soon as either side closes the connection.
+type connection struct {
+	ws     *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	queue    chan outgoing
+	binary   chan v1.BinaryMessage
+	text     chan v1.TextMessage
+	readDone chan struct{}
+
+	// dequeued is signaled whenever the write pump takes an entry from the
+	// queue, allowing the handler to notice the queue having room again
+	dequeued chan struct{}
+
+	writeTimeout time.Duration
+	closeOnce    sync.Once
+	pumps        sync.WaitGroup
+
+	// seq is the sequence number of the last frame written. It is only
+	// accessed by the write pump
+	seq uint64
+}
+
+// defaultWriteTimeout is used if the configured write timeout is not positive,
+// since every write would fail otherwise.
+const defaultWriteTimeout = 10 * time.Second
+
+var errClosed = errors.New("connection closed")
+
+func newConnection(ctx context.Context, ws *websocket.Conn) *connection {
+	c := config.Default.Viper()
+
+	writeTimeout := c.GetDuration(config.ConfigurationKey_WebsocketWriteTimeout)
+	if writeTimeout <= 0 {
+		writeTimeout = defaultWriteTimeout
+	}
+
+	conn := &connection{
+		ws:           ws,
+		queue:        make(chan outgoing, max(c.GetInt(config.ConfigurationKey_WebsocketQueueSize), 1)),
+		binary:       make(chan v1.BinaryMessage),
+		text:         make(chan v1.TextMessage),
+		readDone:     make(chan struct{}),
+		dequeued:     make(chan struct{}, 1),
+		writeTimeout: writeTimeout,
+	}
+	conn.ctx, conn.cancel = context.WithCancelCause(ctx)
+
+	// messages exceeding the limit are answered by the websocket package
+	// with the close code 1009 (message too big)
+	ws.SetReadLimit(c.GetInt64(config.ConfigurationKey_WebsocketMaxMessageSize))
+
+	ws.SetPingHandler(nil) // use the default values provided by the package
+	ws.SetPongHandler(nil) // use the default values provided by the package
+	ws.SetCloseHandler(func(code int, _ string) error {
+		conn.close(code, "")
+		return nil
+	})
+
+	return conn
+}
+
+// start launches the read and write pump.
+func (c *connection) start() {
+	c.pumps.Add(2) //nolint:mnd
+	go c.readPump()
+	go c.writePump()
+}
+
+// wait blocks until both pumps have stopped.
+func (c *connection) wait() {
+	c.pumps.Wait()
+}
+
+// done returns a channel which is closed as soon as the connection is closed.
+func (c *connection) done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// readPump hands the received messages to the handler. After the connection
+// has been closed, the messages are discarded until the client acknowledges
+// the close frame.
+func (c *connection) readPump() {
+	defer c.pumps.Done()
+	defer close(c.readDone)
+
+	for {
+		messageType, message, err := c.ws.ReadMessage()
+		if err != nil {
+			c.cancel(err)
+			return
+		}
+
+		switch messageType {
+		case websocket.BinaryMessage:
+			select {
+			case c.binary <- v1.BinaryMessage{Content: message, ReceivedAt: time.Now()}:
+			case <-c.ctx.Done():
+			}
+		case websocket.TextMessage:
+			select {
+			case c.text <- v1.TextMessage{Content: string(message), ReceivedAt: time.Now()}:
+			case <-c.ctx.Done():
+			}
+		}
+	}
+}
+
+// writePump writes the queued frames. After the connection has been closed,
+// it waits for the read pump to receive the client's close frame before
+// closing the underlying connection, so that the frames already sent are not
+// lost.
+func (c *connection) writePump() {
+	defer c.pumps.Done()
+	defer func() {
+		select {
+		case <-c.readDone:
+		case <-time.After(c.writeTimeout):
+		}
+		_ = c.ws.Close()
+	}()
+
+	pingMessage, _ := websocket.NewPreparedMessage(websocket.PingMessage, []byte("hello there"))
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case out := <-c.queue:
+			select {
+			case c.dequeued <- struct{}{}:
+			default:
+			}
+
+			if out.closeCode != 0 {
+				c.close(out.closeCode, out.closeText)
+				return
+			}
+
+			c.seq++
+			out.frame.Seq = c.seq
+			encoded, err := json.Marshal(out.frame)
+			if err != nil {
+				c.close(websocket.CloseInternalServerErr, "unable to encode frame")
+				return
+			}
+
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+			if err := c.ws.WritePreparedMessage(pingMessage); err != nil {
+				c.cancel(err)
+				return
+			}
+			if err := c.ws.WriteMessage(websocket.TextMessage, encoded); err != nil {
+				c.cancel(err)
+				return
+			}
+		}
+	}
+}
+
+// full reports if the outbound queue is full.
+func (c *connection) full() bool {
+	return len(c.queue) == cap(c.queue)
+}
+
+// enqueue queues the frame for the write pump. If the queue is full, it
+// blocks until the frame is queued or the connection is closed.
+func (c *connection) enqueue(frame v1.Envelope) error {
+	select {
+	case c.queue <- outgoing{frame: frame}:
+		return nil
+	case <-c.ctx.Done():
+		return errClosed
+	}
+}
+
+// send encodes the data and queues it as frame of the supplied type.
+// If the data cannot be encoded, the connection is closed as internal error.
+func (c *connection) send(frameType string, relatedTo v1.CommandID, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		c.close(websocket.CloseInternalServerErr, "unable to encode frame")
+		return err
+	}
+	return c.enqueue(v1.Envelope{Type: frameType, RelatedTo: relatedTo, Data: encoded})
+}
+
+// error queues the error caused by the command with the supplied id.
+func (c *connection) error(relatedTo v1.CommandID, code string, err error, received any) error {
+	return c.send(v1.FrameTypeError, relatedTo, v1.CommandError{
+		Code:         code,
+		Error:        err.Error(),
+		IncomingData: received,
+	})
+}
+
+// closeAfterQueued closes the connection using the RFC 6455 status code after
+// the frames already queued have been written.
+func (c *connection) closeAfterQueued(code int, text string) {
+	select {
+	case c.queue <- outgoing{closeCode: code, closeText: text}:
+	case <-c.ctx.Done():
+	}
+}
+
+// close immediately sends a close frame using the RFC 6455 status code and
+// stops both pumps. Frames still queued are discarded.
+func (c *connection) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		message := websocket.FormatCloseMessage(code, text)
+		_ = c.ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(c.writeTimeout))
+		c.cancel(&websocket.CloseError{Code: code, Text: text})
+	})
+}
@@ -0,0 +1,290 @@
+package v1
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"microservice/engine"
+	config "microservice/internal/configuration"
+	"microservice/probe"
+	"microservice/traefik"
+	v1 "microservice/types/v1"
+)
+
+const testRawData = `{
+	"routers": {
+		"api@docker": {"rule": "PathPrefix(` + "`/api`" + `)", "service": "api@internal", "status": "enabled"}
+	},
+	"services": {
+		"api@internal": {"status": "enabled"}
+	}
+}`
+
+// writeGate holds back the writes of the server while it is held, simulating
+// a client which does not read the frames sent to it.
+type writeGate struct {
+	held     atomic.Bool
+	open     chan struct{}
+	openOnce sync.Once
+}
+
+func newWriteGate() *writeGate {
+	return &writeGate{open: make(chan struct{})}
+}
+
+// hold blocks all following writes until the gate is released.
+func (g *writeGate) hold() {
+	g.held.Store(true)
+}
+
+// release lets the blocked and all following writes pass.
+func (g *writeGate) release() {
+	g.openOnce.Do(func() { close(g.open) })
+}
+
+// gatedListener wraps the accepted connections to let their writes pass the
+// gate.
+type gatedListener struct {
+	net.Listener
+	gate *writeGate
+}
+
+func (l *gatedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &gatedConn{Conn: conn, gate: l.gate}, nil
+}
+
+type gatedConn struct {
+	net.Conn
+	gate *writeGate
+}
+
+func (c *gatedConn) Write(p []byte) (int, error) {
+	if c.gate.held.Load() {
+		<-c.gate.open
+	}
+	return c.Conn.Write(p)
+}
+
+// runEngine replaces the default engine with a running engine polling a fake
+// Traefik API, which serves a router for the path /api.
+func runEngine(t *testing.T) {
+	t.Helper()
+
+	setConfig(t, config.ConfigurationKey_MonitorMinPollInterval, time.Second)
+	setConfig(t, config.ConfigurationKey_LatencyMeasureUpstreams, false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(testRawData))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := traefik.NewClient(traefik.ClientOptions{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	previous := engine.Default
+	engine.Default = engine.New(client, probe.NewRunner())
+	go func() {
+		defer close(stopped)
+		engine.Default.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+		engine.Default = previous
+	})
+}
+
+// subscribe sends a subscribe command for the path /api requesting a snapshot
+// every second.
+func subscribe(t *testing.T, ws *websocket.Conn, id string) {
+	t.Helper()
+
+	command := map[string]any{
+		"command": "subscribe",
+		"id":      id,
+		"data":    map[string]any{"paths": []string{"/api"}, "updateInterval": "PT1S", "snapshots": true},
+	}
+	if err := ws.WriteJSON(command); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+}
+
+func TestConnectionShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		close     func(ws *websocket.Conn) error
+		wantClose int
+	}{
+		{
+			name: "client closes the connection",
+			close: func(ws *websocket.Conn) error {
+				message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")
+				return ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+			},
+			wantClose: websocket.CloseNormalClosure,
+		},
+		{
+			name: "server closes the connection",
+			close: func(ws *websocket.Conn) error {
+				return ws.WriteMessage(websocket.TextMessage, []byte("{"))
+			},
+			wantClose: websocket.CloseUnsupportedData,
+		},
+		{
+			name: "client disappears without closing",
+			close: func(ws *websocket.Conn) error {
+				return ws.UnderlyingConn().Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, config.ConfigurationKey_WebsocketMaxStrikes, 1)
+			ws, returned := dialStatus(t, nil)
+
+			subscribe(t, ws, "subscribe")
+			if _, closeErr := readFrames(t, ws, `"subscribe"`); closeErr != nil {
+				t.Fatalf("connection closed with %d before the subscription was acknowledged", closeErr.Code)
+			}
+			if paths := engine.Default.Hub().Paths(); len(paths) != 1 {
+				t.Fatalf("hub paths = %v, want the subscribed path", paths)
+			}
+
+			if err := tt.close(ws); err != nil {
+				t.Fatalf("closing the connection failed: %v", err)
+			}
+			if tt.wantClose != 0 {
+				_, closeErr := readFrames(t, ws, "")
+				if closeErr == nil || closeErr.Code != tt.wantClose {
+					t.Fatalf("close error = %v, want close code %d", closeErr, tt.wantClose)
+				}
+			}
+
+			// the handler only returns after both pumps stopped
+			waitReturned(t, returned)
+			if paths := engine.Default.Hub().Paths(); len(paths) != 0 {
+				t.Errorf("hub paths = %v, want the subscriber to be detached", paths)
+			}
+		})
+	}
+}
+
+func TestConnectionWriteTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured time.Duration
+		want       time.Duration
+	}{
+		{"configured", 2 * time.Second, 2 * time.Second},
+		{"zero", 0, defaultWriteTimeout},
+		{"negative", -time.Second, defaultWriteTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, config.ConfigurationKey_WebsocketWriteTimeout, tt.configured)
+
+			timeouts := make(chan time.Duration, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ws, err := wsUpgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer ws.Close()
+				timeouts <- newConnection(r.Context(), ws).writeTimeout
+			}))
+			t.Cleanup(server.Close)
+
+			ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer ws.Close()
+
+			if got := <-timeouts; got != tt.want {
+				t.Errorf("write timeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionSlowConsumer(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantClose int
+	}{
+		{policy: SlowConsumerDrop},
+		{policy: SlowConsumerDisconnect, wantClose: websocket.ClosePolicyViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			runEngine(t)
+			setConfig(t, config.ConfigurationKey_WebsocketSlowConsumer, tt.policy)
+			setConfig(t, config.ConfigurationKey_WebsocketQueueSize, 1)
+			setConfig(t, config.ConfigurationKey_WebsocketWriteTimeout, testTimeout)
+			setConfig(t, config.ConfigurationKey_MonitorHeartbeatInterval, 0)
+
+			gate := newWriteGate()
+			ws, returned := dialStatus(t, gate)
+
+			// the write pump blocks on the acknowledgement and the first
+			// snapshot fills the queue. the following snapshots arrive while
+			// the queue is full
+			gate.hold()
+			subscribe(t, ws, "subscribe")
+			time.Sleep(2500 * time.Millisecond)
+			gate.release()
+
+			if tt.wantClose != 0 {
+				_, closeErr := readFrames(t, ws, "")
+				if closeErr == nil || closeErr.Code != tt.wantClose {
+					t.Fatalf("close error = %v, want close code %d", closeErr, tt.wantClose)
+				}
+				waitReturned(t, returned)
+				return
+			}
+
+			// the queued snapshot is followed by the latest snapshot, which
+			// replaced the snapshots produced in the meantime
+			want := []v1.Envelope{
+				{Type: v1.FrameTypeAck, RelatedTo: `"subscribe"`, Seq: 1},
+				{Type: v1.FrameTypeSnapshot, RelatedTo: `"subscribe"`, Seq: 2},
+				{Type: v1.FrameTypeSnapshot, Seq: 3},
+			}
+			for _, w := range want {
+				_ = ws.SetReadDeadline(time.Now().Add(testTimeout))
+				var frame v1.Envelope
+				if err := ws.ReadJSON(&frame); err != nil {
+					t.Fatalf("ReadJSON() of frame %d error = %v", w.Seq, err)
+				}
+				if frame.Type != w.Type || frame.RelatedTo != w.RelatedTo || frame.Seq != w.Seq {
+					t.Errorf("frame = %s %s %d, want %s %s %d",
+						frame.Type, frame.RelatedTo, frame.Seq, w.Type, w.RelatedTo, w.Seq)
+				}
+			}
+
+			message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			_ = ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+			waitReturned(t, returned)
+		})
+	}
+}
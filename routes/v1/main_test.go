@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"microservice/engine"
+	config "microservice/internal/configuration"
+	"microservice/probe"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.Default.Initialize(); err != nil {
+		panic(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine.Default = engine.New(nil, probe.NewRunner())
+	os.Exit(m.Run())
+}
@@ -1,11 +1,11 @@
 package v1
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +13,13 @@ import (
 
 	wisdomTypes "github.com/wisdom-oss/common-go/v3/types"
 
-	"microservice/traefik"
+	"microservice/engine"
+	config "microservice/internal/configuration"
 	v1 "microservice/types/v1"
 	commands "microservice/types/v1/command-data"
 )
 
 const bufferSizeLimit = 2048
-const defaultTickInterval = 15 * time.Second
 
 var wsUpgrader = websocket.Upgrader{
 	ReadBufferSize:  bufferSizeLimit,
@@ -46,175 +46,174 @@ func StatusWS(c *gin.Context) {
 		return
 	}
 
-	socketCtx, cancel := context.WithCancelCause(c)
+	conn := newConnection(c, ws)
+	conn.start()
+	defer conn.wait()
 
-	ws.SetPingHandler(nil) // use the default values provided by the package
-	ws.SetPongHandler(nil) // use the default values provided by the package
-	ws.SetCloseHandler(func(code int, text string) error {
-		message := websocket.FormatCloseMessage(code, text)
-		_ = ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
-		cancel(errors.New("recevied close message"))
-		_ = ws.Close()
-		return nil
-	})
+	subscriber := engine.NewSubscriber()
+	defer engine.Default.Unsubscribe(subscriber)
 
-	binaryMessages := make(chan v1.BinaryMessage)
-	textMessages := make(chan v1.TextMessage)
-
-	_, _ = startReceivingMessages(socketCtx, ws, binaryMessages, textMessages)
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("websocket connection failed", "panic", r)
+			conn.close(websocket.CloseInternalServerErr, "internal error")
+		}
+	}()
 
-	t := time.NewTicker(defaultTickInterval)
-	defer t.Stop()
+	cfg := config.Default.Viper()
+	// heartbeats are disabled if the interval is not positive, leaving the
+	// heartbeat channel nil
+	var heartbeat <-chan time.Time
+	if interval := cfg.GetDuration(config.ConfigurationKey_MonitorHeartbeatInterval); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
 
-	pingMessage, _ := websocket.NewPreparedMessage(websocket.PingMessage, []byte("hello there"))
+	disconnectSlowConsumers := cfg.GetString(config.ConfigurationKey_WebsocketSlowConsumer) == SlowConsumerDisconnect
+
+	// every invalid message is a strike against the client. after reaching the
+	// limit, the client is disconnected
+	maxStrikes := cfg.GetInt(config.ConfigurationKey_WebsocketMaxStrikes)
+	strikes := 0
+	reject := func(relatedTo v1.CommandID, code string, err error, received any) bool {
+		_ = conn.error(relatedTo, code, err, received)
+		strikes++
+		if maxStrikes <= 0 || strikes < maxStrikes {
+			return false
+		}
 
-	var subscribedPathsLock sync.Mutex
-	subscribedPaths := make([]string, 0)
+		closeCode := websocket.ClosePolicyViolation
+		if code == v1.ErrorCodeInvalidJSON {
+			closeCode = websocket.CloseUnsupportedData
+		}
+		conn.closeAfterQueued(closeCode, "too many invalid messages")
+		return true
+	}
 
 	var command v1.Command
 	for {
+		command = v1.Command{}
+
+		// if intermediate updates are dropped, the status frames are left to
+		// the subscriber while the queue is full. the subscriber only keeps
+		// the latest frame and sends the full statuses of the dropped paths
+		statusFrames := subscriber.C()
+		if !disconnectSlowConsumers && conn.full() {
+			statusFrames = nil
+		}
+
+		var received any
+		var err error
 		select {
-		case <-socketCtx.Done():
+		case <-conn.done():
 			return
-		case msg := <-binaryMessages:
-			err := json.Unmarshal(msg.Content, &command)
-			if err != nil {
-				response := v1.CommandError{
-					IncomingMessageID: command.ID,
-					Error:             err.Error(),
-					IncomingData:      msg.Content,
-				}
-				_ = ws.WriteJSON(response)
-				return
-			}
-		case msg := <-textMessages:
-			err := json.Unmarshal([]byte(msg.Content), &command)
-			if err != nil {
-				response := v1.CommandError{
-					IncomingMessageID: command.ID,
-					Error:             err.Error(),
-					IncomingData:      msg.Content,
-				}
-				_ = ws.WriteJSON(response)
+		case msg := <-conn.binary:
+			received = msg.Content
+			err = json.Unmarshal(msg.Content, &command)
+		case msg := <-conn.text:
+			received = msg.Content
+			err = json.Unmarshal([]byte(msg.Content), &command)
+		case frame := <-statusFrames:
+			if conn.full() {
+				conn.close(websocket.ClosePolicyViolation, "client does not keep up with the updates")
 				return
 			}
-		case <-t.C:
-			if len(subscribedPaths) == 0 {
-				continue
+			_ = conn.enqueue(frame)
+			continue
+		case <-conn.dequeued:
+			// the queue may have room for the status frames again
+			continue
+		case at := <-heartbeat:
+			// heartbeats are skipped while frames are waiting to be written
+			if !conn.full() {
+				_ = conn.send(v1.FrameTypeHeartbeat, "", v1.Heartbeat{Timestamp: at})
 			}
-			var statuses []v1.ServiceStatus
+			continue
+		}
 
-			statuses, err := traefik.ServiceStatus(subscribedPaths...)
-			if err != nil {
-				response := v1.CommandError{
-					IncomingMessageID: command.ID,
-					Error:             err.Error(),
-					IncomingData:      command,
-				}
-				_ = ws.WriteJSON(response)
-				continue
+		// an id of the wrong type is valid json, but an invalid command
+		if errors.Is(err, v1.ErrInvalidCommandID) {
+			if reject("", v1.ErrorCodeInvalidCommand, err, received) {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			if reject(command.ID, v1.ErrorCodeInvalidJSON, err, received) {
+				return
 			}
-			_ = ws.WritePreparedMessage(pingMessage)
-			_ = ws.WriteJSON(statuses)
 			continue
 		}
 
 		if err := command.Validate(); err != nil {
-			response := v1.CommandError{
-				IncomingMessageID: command.ID,
-				Error:             err.Error(),
-				IncomingData:      command,
+			if reject(command.ID, v1.ErrorCodeInvalidCommand, err, command) {
+				return
 			}
-			_ = ws.WriteJSON(response)
 			continue
 		}
 
-		var response any
+		var commandErr error
+		errorCode := v1.ErrorCodeInvalidData
 
 		switch command.Command {
-		case "subscribe":
+		case "subscribe", "replace":
 			var data commands.Subscribe
 			err := json.Unmarshal(command.Data, &data)
 			if err != nil {
-				response = v1.CommandError{
-					IncomingMessageID: command.ID,
-					Error:             err.Error(),
-					IncomingData:      command,
-				}
+				commandErr = err
 				break
 			}
 
 			if err := data.Validate(); err != nil {
-				response = v1.CommandError{
-					IncomingMessageID: command.ID,
-					Error:             err.Error(),
-					IncomingData:      command,
-				}
+				commandErr = err
 				break
 			}
 
-			if data.Interval.ToTimeDuration() == time.Duration(0) {
-				t.Reset(defaultTickInterval)
-			} else {
-				t.Reset(data.Interval.ToTimeDuration())
+			options := engine.Options{
+				Interval:         data.Interval.ToTimeDuration(),
+				Detailed:         data.Detailed,
+				ExtendedStatuses: data.ExtendedStatuses,
+				Snapshots:        data.Snapshots,
+				RelatedTo:        command.ID,
 			}
 
-			subscribedPathsLock.Lock()
-			subscribedPaths = data.Paths
-			subscribedPathsLock.Unlock()
+			// the engine delivers the current statuses of the subscribed paths
+			// as soon as they are available, relating them to the command
+			if command.Command == "replace" {
+				engine.Default.Replace(subscriber, data.Paths, options)
+			} else {
+				engine.Default.Subscribe(subscriber, data.Paths, options)
+			}
 
-			statuses, err := traefik.ServiceStatus(subscribedPaths...)
-			if err != nil {
-				response := v1.CommandError{
-					IncomingMessageID: command.ID,
-					Error:             err.Error(),
-					IncomingData:      command,
+		case "unsubscribe":
+			var data commands.Unsubscribe
+			if len(command.Data) > 0 {
+				if err := json.Unmarshal(command.Data, &data); err != nil {
+					commandErr = err
+					break
 				}
-				_ = ws.WriteJSON(response)
-				break
 			}
 
-			response = statuses
-
-		case "unsubscribe":
-			subscribedPathsLock.Lock()
-			subscribedPaths = []string{}
-			subscribedPathsLock.Unlock()
+			if err := data.Validate(); err != nil {
+				commandErr = err
+				break
+			}
 
-		}
+			engine.Default.Unsubscribe(subscriber, data.Paths...)
 
-		_ = ws.WritePreparedMessage(pingMessage)
-		if response != nil {
-			_ = ws.WriteJSON(response)
+		default:
+			commandErr = fmt.Errorf("unknown command %q", command.Command)
+			errorCode = v1.ErrorCodeUnknownCommand
 		}
-	}
-
-}
-
-func startReceivingMessages(ctx context.Context, ws *websocket.Conn, b chan v1.BinaryMessage, t chan v1.TextMessage) (context.Context, context.CancelCauseFunc) { //nolint:lll
-	receiverContext, cancel := context.WithCancelCause(ctx)
 
-	go func() {
-		for {
-			select {
-			case <-receiverContext.Done():
+		if commandErr != nil {
+			if reject(command.ID, errorCode, commandErr, command) {
 				return
-			default:
-				messageType, message, err := ws.ReadMessage()
-				if err != nil {
-					cancel(err)
-					return
-				}
-
-				switch messageType {
-				case websocket.BinaryMessage:
-					b <- v1.BinaryMessage{Content: message, ReceivedAt: time.Now()}
-				case websocket.TextMessage:
-					t <- v1.TextMessage{Content: string(message), ReceivedAt: time.Now()}
-				}
 			}
+			continue
 		}
-	}()
+		_ = conn.send(v1.FrameTypeAck, command.ID, v1.Ack{Command: command.Command, Paths: subscriber.Paths()})
+	}
 
-	return receiverContext, cancel
 }
@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// testTimeout limits how long the tests wait for frames and for the handler
+// to return.
+const testTimeout = 5 * time.Second
+
+// setConfig overrides the configuration key for the duration of the test.
+func setConfig(t *testing.T, key string, value any) {
+	t.Helper()
+
+	c := config.Default.Viper()
+	previous := c.Get(key)
+	c.Set(key, value)
+	t.Cleanup(func() { c.Set(key, previous) })
+}
+
+// dialStatus starts a server handling the websocket and connects to it. The
+// returned channel is closed as soon as the handler returned, which it only
+// does after both pumps of the connection have stopped.
+// If a gate is supplied, the writes of the server pass it.
+func dialStatus(t *testing.T, gate *writeGate) (*websocket.Conn, <-chan struct{}) {
+	t.Helper()
+
+	returned := make(chan struct{})
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		defer close(returned)
+		StatusWS(c)
+	})
+	server := httptest.NewUnstartedServer(router)
+	if gate != nil {
+		server.Listener = &gatedListener{Listener: server.Listener, gate: gate}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	if gate != nil {
+		t.Cleanup(gate.release)
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws, returned
+}
+
+// readFrames reads the frames until the frame relating to the command id or
+// until the connection is closed. The close error is returned if the
+// connection has been closed.
+func readFrames(t *testing.T, ws *websocket.Conn, until v1.CommandID) ([]v1.Envelope, *websocket.CloseError) {
+	t.Helper()
+
+	var frames []v1.Envelope
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(testTimeout))
+		var frame v1.Envelope
+		err := ws.ReadJSON(&frame)
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			return frames, closeErr
+		}
+		if err != nil {
+			t.Fatalf("ReadJSON() error = %v", err)
+		}
+
+		frames = append(frames, frame)
+		if until != "" && frame.RelatedTo == until {
+			return frames, nil
+		}
+	}
+}
+
+// waitReturned fails the test if the handler does not return in time.
+func waitReturned(t *testing.T, returned <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-returned:
+	case <-time.After(testTimeout):
+		t.Fatal("handler did not return after the connection has been closed")
+	}
+}
+
+// errorCodes returns the codes of the error frames.
+func errorCodes(t *testing.T, frames []v1.Envelope) []string {
+	t.Helper()
+
+	var codes []string
+	for _, frame := range frames {
+		if frame.Type != v1.FrameTypeError {
+			continue
+		}
+		var commandErr v1.CommandError
+		if err := json.Unmarshal(frame.Data, &commandErr); err != nil {
+			t.Fatalf("unable to decode error frame: %v", err)
+		}
+		codes = append(codes, commandErr.Code)
+	}
+	return codes
+}
+
+func TestStatusStrikes(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxStrikes    int
+		messages      []string
+		wantCodes     []string
+		wantRelatedTo []v1.CommandID
+		wantClose     int
+	}{
+		{
+			name:       "invalid json",
+			maxStrikes: 3,
+			messages:   []string{"{", "[", "subscribe"},
+			wantCodes: []string{
+				v1.ErrorCodeInvalidJSON, v1.ErrorCodeInvalidJSON, v1.ErrorCodeInvalidJSON,
+			},
+			wantRelatedTo: []v1.CommandID{"", "", ""},
+			wantClose:     websocket.CloseUnsupportedData,
+		},
+		{
+			name:       "invalid and unknown commands",
+			maxStrikes: 3,
+			messages:   []string{`{"id": "a"}`, `{"command": "poll", "id": 2}`, `{"command": "subscribe", "id": {}}`},
+			wantCodes: []string{
+				v1.ErrorCodeInvalidCommand, v1.ErrorCodeUnknownCommand, v1.ErrorCodeInvalidCommand,
+			},
+			wantRelatedTo: []v1.CommandID{`"a"`, "2", ""},
+			wantClose:     websocket.ClosePolicyViolation,
+		},
+		{
+			name:       "last strike determines the close code",
+			maxStrikes: 2,
+			messages:   []string{"{", `{"command": "poll", "id": "b"}`},
+			wantCodes: []string{
+				v1.ErrorCodeInvalidJSON, v1.ErrorCodeUnknownCommand,
+			},
+			wantRelatedTo: []v1.CommandID{"", `"b"`},
+			wantClose:     websocket.ClosePolicyViolation,
+		},
+		{
+			name:       "below the limit",
+			maxStrikes: 3,
+			messages:   []string{"{", `{"command": "poll", "id": "b"}`},
+			wantCodes: []string{
+				v1.ErrorCodeInvalidJSON, v1.ErrorCodeUnknownCommand,
+			},
+			wantRelatedTo: []v1.CommandID{"", `"b"`},
+		},
+		{
+			name:       "strikes disabled",
+			maxStrikes: 0,
+			messages:   []string{"{", "{", "{", "{"},
+			wantCodes: []string{
+				v1.ErrorCodeInvalidJSON, v1.ErrorCodeInvalidJSON, v1.ErrorCodeInvalidJSON, v1.ErrorCodeInvalidJSON,
+			},
+			wantRelatedTo: []v1.CommandID{"", "", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, config.ConfigurationKey_WebsocketMaxStrikes, tt.maxStrikes)
+			ws, returned := dialStatus(t, nil)
+
+			for _, message := range tt.messages {
+				if err := ws.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+					t.Fatalf("WriteMessage() error = %v", err)
+				}
+			}
+
+			// a connection left open still accepts valid commands
+			var until v1.CommandID
+			if tt.wantClose == 0 {
+				until = `"done"`
+				if err := ws.WriteJSON(map[string]any{"command": "unsubscribe", "id": "done"}); err != nil {
+					t.Fatalf("WriteJSON() error = %v", err)
+				}
+			}
+
+			frames, closeErr := readFrames(t, ws, until)
+			if got := errorCodes(t, frames); !slices.Equal(got, tt.wantCodes) {
+				t.Errorf("error codes = %v, want %v", got, tt.wantCodes)
+			}
+			var relatedTo []v1.CommandID
+			for _, frame := range frames {
+				if frame.Type == v1.FrameTypeError {
+					relatedTo = append(relatedTo, frame.RelatedTo)
+				}
+			}
+			if !slices.Equal(relatedTo, tt.wantRelatedTo) {
+				t.Errorf("relatedTo = %v, want %v", relatedTo, tt.wantRelatedTo)
+			}
+
+			if tt.wantClose == 0 {
+				if closeErr != nil {
+					t.Fatalf("connection closed with %d, want it to stay open", closeErr.Code)
+				}
+				if last := frames[len(frames)-1]; last.Type != v1.FrameTypeAck {
+					t.Errorf("last frame type = %s, want %s", last.Type, v1.FrameTypeAck)
+				}
+				return
+			}
+			if closeErr == nil || closeErr.Code != tt.wantClose {
+				t.Fatalf("close error = %v, want close code %d", closeErr, tt.wantClose)
+			}
+			waitReturned(t, returned)
+		})
+	}
+}
+
+func TestStatusMessageTooBig(t *testing.T) {
+	setConfig(t, config.ConfigurationKey_WebsocketMaxMessageSize, 64)
+	ws, returned := dialStatus(t, nil)
+
+	message := `{"command": "subscribe", "id": "` + strings.Repeat("a", 128) + `"}`
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+
+	frames, closeErr := readFrames(t, ws, "")
+	if len(frames) != 0 {
+		t.Errorf("frames = %+v, want none", frames)
+	}
+	if closeErr == nil || closeErr.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("close error = %v, want close code %d", closeErr, websocket.CloseMessageTooBig)
+	}
+	waitReturned(t, returned)
+}
@@ -0,0 +1,104 @@
+package traefik
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests which are rejected without
+// contacting the Traefik API, since the previous requests failed.
+var ErrCircuitOpen = errors.New("traefik api is unavailable, circuit breaker is open")
+
+// breaker is a circuit breaker preventing requests from piling up on an
+// unavailable Traefik API.
+// After the configured number of consecutive failures the breaker opens and
+// rejects all requests until the backoff elapsed. Afterwards, a single request
+// is let through. If it fails, the breaker opens again with a doubled backoff.
+type breaker struct {
+	threshold      int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	lock      sync.Mutex
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports if a request may be sent to the api.
+func (b *breaker) allow() error {
+	if b == nil || b.threshold <= 0 {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	if b.probing || time.Now().Before(b.openUntil) {
+		return fmt.Errorf("%w until %s", ErrCircuitOpen, b.openUntil.Format(time.RFC3339))
+	}
+
+	// the backoff elapsed, let a single request probe the api
+	b.probing = true
+	return nil
+}
+
+// unavailable reports if the consecutive failures reached the threshold.
+// If the breaker is disabled, a single failure makes the api unavailable.
+func (b *breaker) unavailable() bool {
+	if b == nil {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.failures >= max(b.threshold, 1)
+}
+
+// record updates the breaker with the outcome of a request.
+// Requests canceled by the caller are not considered as failures. The
+// failures are counted even if the breaker is disabled, as they determine if
+// the api is unavailable.
+func (b *breaker) record(res *http.Response, err error) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		b.probing = false
+		return
+	}
+
+	if err == nil && res.StatusCode < http.StatusInternalServerError {
+		b.failures = 0
+		b.backoff = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return
+	}
+
+	switch {
+	case b.backoff == 0:
+		b.backoff = b.initialBackoff
+	case b.probing:
+		b.backoff = min(2*b.backoff, b.maxBackoff) //nolint:mnd
+	}
+	b.openUntil = time.Now().Add(b.backoff)
+	b.probing = false
+}
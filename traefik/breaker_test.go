@@ -0,0 +1,154 @@
+package traefik
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	responseOk    = &http.Response{StatusCode: http.StatusOK}
+	responseError = &http.Response{StatusCode: http.StatusBadGateway}
+	errTransport  = errors.New("connection refused")
+)
+
+// elapse lets the backoff of the open breaker elapse.
+func (b *breaker) elapse() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.openUntil = time.Now().Add(-time.Millisecond)
+}
+
+func newTestBreaker() *breaker {
+	return &breaker{threshold: 2, initialBackoff: time.Minute, maxBackoff: 3 * time.Minute}
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := newTestBreaker()
+
+	b.record(nil, errTransport)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after a single failure = %v", err)
+	}
+
+	b.record(responseError, nil)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() after reaching the threshold = %v, want %v", err, ErrCircuitOpen)
+	}
+	if b.backoff != time.Minute {
+		t.Errorf("backoff = %s, want %s", b.backoff, time.Minute)
+	}
+}
+
+func TestBreakerResetsOnSuccess(t *testing.T) {
+	b := newTestBreaker()
+
+	b.record(nil, errTransport)
+	b.record(responseOk, nil)
+	b.record(nil, errTransport)
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() = %v, failures are not consecutive", err)
+	}
+
+	// client errors do not indicate an unavailable api
+	b.record(&http.Response{StatusCode: http.StatusNotFound}, nil)
+	b.record(nil, errTransport)
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() = %v, 4xx responses are no failures", err)
+	}
+}
+
+func TestBreakerProbesAfterBackoff(t *testing.T) {
+	b := newTestBreaker()
+	b.record(nil, errTransport)
+	b.record(nil, errTransport)
+
+	b.elapse()
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after the backoff = %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() while probing = %v, want only a single probing request", err)
+	}
+
+	// a failed probe doubles the backoff up to the maximum
+	b.record(nil, errTransport)
+	if b.backoff != 2*time.Minute {
+		t.Errorf("backoff = %s, want %s", b.backoff, 2*time.Minute)
+	}
+	b.elapse()
+	_ = b.allow()
+	b.record(nil, errTransport)
+	if b.backoff != 3*time.Minute {
+		t.Errorf("backoff = %s, want the maximum of %s", b.backoff, 3*time.Minute)
+	}
+
+	// a successful probe closes the breaker
+	b.elapse()
+	_ = b.allow()
+	b.record(responseOk, nil)
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() after a successful probe = %v", err)
+	}
+	if b.backoff != 0 {
+		t.Errorf("backoff = %s, want it to be reset", b.backoff)
+	}
+}
+
+func TestBreakerIgnoresCanceledRequests(t *testing.T) {
+	b := newTestBreaker()
+	b.record(nil, context.Canceled)
+	b.record(nil, context.Canceled)
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() = %v, canceled requests are no failures", err)
+	}
+
+	// a canceled probe lets the next request probe the api
+	b.record(nil, errTransport)
+	b.record(nil, errTransport)
+	b.elapse()
+	_ = b.allow()
+	b.record(nil, context.Canceled)
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() after a canceled probe = %v", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := &breaker{}
+	for range 5 {
+		b.record(nil, errTransport)
+	}
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() = %v, a zero threshold disables the breaker", err)
+	}
+
+	var nilBreaker *breaker
+	if err := nilBreaker.allow(); err != nil {
+		t.Errorf("allow() on nil breaker = %v", err)
+	}
+}
+
+func TestBreakerUnavailable(t *testing.T) {
+	b := newTestBreaker()
+	b.record(nil, errTransport)
+	if b.unavailable() {
+		t.Error("unavailable() after a single failure, want the threshold to be reached")
+	}
+	b.record(responseError, nil)
+	if !b.unavailable() {
+		t.Error("unavailable() = false after reaching the threshold")
+	}
+	b.record(responseOk, nil)
+	if b.unavailable() {
+		t.Error("unavailable() after a successful request")
+	}
+
+	disabled := &breaker{}
+	disabled.record(nil, errTransport)
+	if !disabled.unavailable() {
+		t.Error("unavailable() = false after a failure without breaker")
+	}
+}
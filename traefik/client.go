@@ -0,0 +1,296 @@
+package traefik
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	config "microservice/internal/configuration"
+)
+
+// DefaultClient is the client configured from the service's configuration.
+// It is set up during the startup of the service.
+var DefaultClient *Client
+
+var ErrUnexpectedStatus = errors.New("traefik api responded with unexpected status")
+
+// ErrEndpointNotFound is returned in addition to [ErrUnexpectedStatus] if the
+// requested endpoint does not exist (e.g. on Traefik versions without it).
+var ErrEndpointNotFound = errors.New("traefik api endpoint does not exist")
+
+// Client accesses the API of a Traefik instance.
+// Every request is bound to the supplied context and additionally limited by
+// the configured timeout.
+type Client struct {
+	baseUrl  string
+	basePath string
+	timeout  time.Duration
+
+	username string
+	password string
+	token    string
+
+	http    *http.Client
+	rawData rawDataState
+	breaker *breaker
+}
+
+// ClientOptions contain the settings used to create a new [Client].
+type ClientOptions struct {
+	Endpoint string        // url of the traefik api (e.g. http://traefik:8080)
+	BasePath string        // path under which the api is served (default: /api)
+	Timeout  time.Duration // timeout applied to every single request
+
+	Username string // username used for basic authentication
+	Password string // password used for basic authentication
+	Token    string // bearer token, takes precedence over basic authentication
+
+	CACertificate     []byte // pem encoded certificates trusted for the api
+	ClientCertificate []byte // pem encoded certificate used for mTLS
+	ClientKey         []byte // pem encoded key used for mTLS
+
+	FailureThreshold int           // consecutive failures opening the circuit breaker, zero disables it
+	InitialBackoff   time.Duration // time the circuit breaker stays open after opening
+	MaxBackoff       time.Duration // upper limit of the doubled backoff
+}
+
+// NewClient creates a new client using the supplied options.
+func NewClient(opts ClientOptions) (*Client, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("no traefik api endpoint configured")
+	}
+
+	if opts.BasePath == "" {
+		opts.BasePath = "/api"
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(opts.CACertificate) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(opts.CACertificate) {
+			return nil, errors.New("unable to parse configured traefik ca certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(opts.ClientCertificate) > 0 || len(opts.ClientKey) > 0 {
+		certificate, err := tls.X509KeyPair(opts.ClientCertificate, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load traefik client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unsupported default http transport")
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Client{
+		baseUrl:  opts.Endpoint,
+		basePath: opts.BasePath,
+		timeout:  opts.Timeout,
+		username: opts.Username,
+		password: opts.Password,
+		token:    opts.Token,
+		http:     &http.Client{Transport: transport},
+		breaker: &breaker{
+			threshold:      opts.FailureThreshold,
+			initialBackoff: opts.InitialBackoff,
+			maxBackoff:     max(opts.MaxBackoff, opts.InitialBackoff),
+		},
+	}, nil
+}
+
+// NewClientFromConfiguration creates a new client using the traefik.*
+// configuration keys.
+// Certificates and keys may either be supplied directly as PEM encoded value
+// (e.g. when reading the configuration from a vault) or as path to a file.
+func NewClientFromConfiguration() (*Client, error) {
+	c := config.Default.Viper()
+
+	caCertificate, err := pemValue(
+		config.ConfigurationKey_TraefikCACertificate, config.ConfigurationKey_TraefikCACertificateFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCertificate, err := pemValue(
+		config.ConfigurationKey_TraefikClientCertificate, config.ConfigurationKey_TraefikClientCertificateFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	clientKey, err := pemValue(
+		config.ConfigurationKey_TraefikClientKey, config.ConfigurationKey_TraefikClientKeyFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(ClientOptions{
+		Endpoint:          c.GetString(config.ConfigurationKey_TraefikAPIEndpoint),
+		BasePath:          c.GetString(config.ConfigurationKey_TraefikAPIBasePath),
+		Timeout:           c.GetDuration(config.ConfigurationKey_TraefikTimeout),
+		Username:          c.GetString(config.ConfigurationKey_TraefikUsername),
+		Password:          c.GetString(config.ConfigurationKey_TraefikPassword),
+		Token:             c.GetString(config.ConfigurationKey_TraefikToken),
+		CACertificate:     caCertificate,
+		ClientCertificate: clientCertificate,
+		ClientKey:         clientKey,
+		FailureThreshold:  c.GetInt(config.ConfigurationKey_TraefikBreakerFailureThreshold),
+		InitialBackoff:    c.GetDuration(config.ConfigurationKey_TraefikBreakerInitialBackoff),
+		MaxBackoff:        c.GetDuration(config.ConfigurationKey_TraefikBreakerMaxBackoff),
+	})
+}
+
+func pemValue(valueKey, fileKey string) ([]byte, error) {
+	c := config.Default.Viper()
+	if value := c.GetString(valueKey); value != "" {
+		return []byte(value), nil
+	}
+
+	file := c.GetString(fileKey)
+	if file == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	contents, err := os.ReadFile(file) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file configured in %s: %w", fileKey, err)
+	}
+	return contents, nil
+}
+
+// endpoint builds the url of an api endpoint.
+func (c *Client) endpoint(elements ...string) (string, error) {
+	return url.JoinPath(c.baseUrl, append([]string{c.basePath}, elements...)...)
+}
+
+// get requests the api endpoint and decodes the response into v.
+func (c *Client) get(ctx context.Context, v any, elements ...string) error {
+	_, err := c.getWithQuery(ctx, v, nil, elements...)
+	return err
+}
+
+// getWithQuery requests the api endpoint using the supplied query parameters
+// and decodes the response into v.
+// The response headers are returned to allow reading pagination information.
+func (c *Client) getWithQuery(ctx context.Context, v any, query url.Values, elements ...string) (http.Header, error) {
+	res, err := c.requestWithQuery(ctx, query, elements...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %w", ErrUnexpectedStatus, ErrEndpointNotFound)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedStatus, res.Status)
+	}
+
+	return res.Header, json.NewDecoder(res.Body).Decode(v)
+}
+
+// request executes a request against the api endpoint.
+// The caller is responsible for closing the response body.
+func (c *Client) request(ctx context.Context, elements ...string) (*http.Response, error) {
+	return c.requestWithQuery(ctx, nil, elements...)
+}
+
+func (c *Client) requestWithQuery(ctx context.Context, query url.Values, elements ...string) (*http.Response, error) {
+	uri, err := c.endpoint(elements...)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		res, err := c.do(ctx, uri)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+		return res, nil
+	}
+
+	return c.do(ctx, uri)
+}
+
+// do executes the request unless the circuit breaker rejects it.
+func (c *Client) do(ctx context.Context, uri string) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	res, err := c.http.Do(req)
+	c.breaker.record(res, err)
+	return res, err
+}
+
+// Unavailable reports if the Traefik API is considered unavailable, since the
+// latest requests failed consecutively as often as configured for the circuit
+// breaker.
+func (c *Client) Unavailable() bool {
+	return c.breaker.unavailable()
+}
+
+// cancelOnClose releases the timeout context of a request as soon as its
+// response body has been closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// Ping checks if the api is reachable by requesting its overview.
+func (c *Client) Ping(ctx context.Context) error {
+	res, err := c.request(ctx, "/overview")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New("api gateway responded with not-OK (status != 200) to overview request")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package traefik
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// forwardAuthResult contains the outcome of probing a forwardAuth address.
+type forwardAuthResult struct {
+	status string
+	err    error
+}
+
+var errForwardAuthServerError = errors.New("forward auth address responded with a server error")
+
+var forwardAuthClient = &http.Client{
+	// the responses of the authentication server are not interpreted, so
+	// redirects do not need to be followed
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// probeForwardAuth requests every supplied address once.
+// Addresses which cannot be reached result in a down status, as Traefik
+// rejects all requests if the authentication server is unavailable.
+// Server errors result in a limited status.
+// Every other response is considered healthy, since rejecting the
+// unauthenticated probe is the expected behavior of an authentication server.
+func probeForwardAuth(ctx context.Context, addresses []string) map[string]forwardAuthResult {
+	timeout := config.Default.Viper().GetDuration(config.ConfigurationKey_MiddlewaresForwardAuthTimeout)
+
+	unique := make(map[string]struct{}, len(addresses))
+	for _, address := range addresses {
+		unique[address] = struct{}{}
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]forwardAuthResult, len(unique))
+
+	for address := range unique {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := probeForwardAuthAddress(ctx, address, timeout)
+
+			lock.Lock()
+			results[address] = result
+			lock.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+func probeForwardAuthAddress(ctx context.Context, address string, timeout time.Duration) forwardAuthResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return forwardAuthResult{status: v1.ServiceStatusDown, err: err}
+	}
+
+	res, err := forwardAuthClient.Do(req)
+	if err != nil {
+		return forwardAuthResult{status: v1.ServiceStatusDown, err: err}
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return forwardAuthResult{
+			status: v1.ServiceStatusIssues,
+			err:    fmt.Errorf("%w: %s", errForwardAuthServerError, res.Status),
+		}
+	}
+
+	return forwardAuthResult{status: v1.ServiceStatusOk}
+}
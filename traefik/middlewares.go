@@ -0,0 +1,70 @@
+package traefik
+
+import (
+	v1 "microservice/types/v1"
+)
+
+// middlewareStatusMissing is used for middlewares referenced by a router but
+// not known to Traefik.
+const middlewareStatusMissing = "missing"
+
+// forwardAuthReference points to a forwardAuth middleware in a resolved
+// middleware chain.
+type forwardAuthReference struct {
+	index   int // index of the middleware in the chain
+	address string
+}
+
+// middlewareChain resolves the middlewares referenced by a router in the
+// order they are applied.
+// Chain middlewares are followed by the middlewares they contain.
+func (s *Snapshot) middlewareChain(protocol, provider string, names []string) ([]v1.MiddlewareStatus, []forwardAuthReference) { //nolint:lll
+	var chain []v1.MiddlewareStatus
+	var forwardAuth []forwardAuthReference
+	s.appendMiddlewares(&chain, &forwardAuth, protocol, provider, names, 0)
+	return chain, forwardAuth
+}
+
+func (s *Snapshot) appendMiddlewares(
+	chain *[]v1.MiddlewareStatus, forwardAuth *[]forwardAuthReference,
+	protocol, provider string, names []string, depth int,
+) {
+	for _, name := range names {
+		qualified := qualifiedName(name, provider)
+		middleware, ok := s.Middleware(protocol, qualified)
+		if !ok {
+			*chain = append(*chain, v1.MiddlewareStatus{
+				Name:    qualified,
+				Status:  middlewareStatusMissing,
+				Errors:  []string{"middleware does not exist"},
+				Flagged: true,
+			})
+			continue
+		}
+
+		*chain = append(*chain, v1.MiddlewareStatus{
+			Name:    qualified,
+			Type:    middleware.Type,
+			Status:  middleware.Status,
+			Errors:  middleware.Err,
+			Flagged: middleware.Status == resourceStatusDisabled || len(middleware.Err) > 0,
+		})
+
+		if middleware.ForwardAuth != nil && middleware.ForwardAuth.Address != "" {
+			*forwardAuth = append(*forwardAuth, forwardAuthReference{
+				index:   len(*chain) - 1,
+				address: middleware.ForwardAuth.Address,
+			})
+		}
+
+		if middleware.Chain != nil {
+			if depth >= maxServiceDepth {
+				entry := &(*chain)[len(*chain)-1]
+				entry.Errors = append(entry.Errors, "maximum middleware nesting depth exceeded")
+				entry.Flagged = true
+				continue
+			}
+			s.appendMiddlewares(chain, forwardAuth, protocol, middleware.Provider, middleware.Chain.Middlewares, depth+1)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package traefik
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	v1 "microservice/types/v1"
+)
+
+// testMiddlewares contains http middlewares as returned by the rawdata
+// endpoint.
+const testMiddlewares = `{
+	"strip@docker": {"stripPrefix": {"prefixes": ["/api"]}, "status": "enabled"},
+	"auth@file": {"forwardAuth": {"address": "http://auth"}, "status": "enabled"},
+	"disabled@docker": {"headers": {}, "status": "disabled"},
+	"broken@docker": {"headers": {}, "status": "warning", "error": ["invalid header"]},
+	"secured@docker": {"chain": {"middlewares": ["strip", "auth@file"]}, "status": "enabled"},
+	"nested@docker": {"chain": {"middlewares": ["secured"]}, "status": "enabled"},
+	"shared@file": {"chain": {"middlewares": ["auth"]}, "status": "enabled"},
+	"loop@docker": {"chain": {"middlewares": ["loop"]}, "status": "enabled"}
+}`
+
+func newTestMiddlewareSnapshot(t *testing.T) *Snapshot {
+	t.Helper()
+
+	var middlewares map[string]v1.Middleware
+	if err := json.Unmarshal([]byte(testMiddlewares), &middlewares); err != nil {
+		t.Fatalf("unable to decode test middlewares: %v", err)
+	}
+
+	snapshot := newSnapshot()
+	snapshot.addMiddlewares(ProtocolHTTP, middlewares)
+	return snapshot
+}
+
+// describeChain formats the chain as name:type:status entries, appending ! to
+// flagged middlewares.
+func describeChain(chain []v1.MiddlewareStatus) []string {
+	var entries []string
+	for _, m := range chain {
+		entry := strings.Join([]string{m.Name, m.Type, m.Status}, ":")
+		if m.Flagged {
+			entry += "!"
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	tests := []struct {
+		name            string
+		middlewares     []string
+		want            []string
+		wantForwardAuth []int
+	}{
+		{
+			name:        "no middlewares",
+			middlewares: nil,
+			want:        nil,
+		},
+		{
+			name:        "middlewares of the router's provider",
+			middlewares: []string{"strip"},
+			want:        []string{"strip@docker:stripprefix:enabled"},
+		},
+		{
+			name:            "middlewares of other providers",
+			middlewares:     []string{"strip", "auth@file"},
+			want:            []string{"strip@docker:stripprefix:enabled", "auth@file:forwardauth:enabled"},
+			wantForwardAuth: []int{1},
+		},
+		{
+			name:        "missing middlewares are flagged",
+			middlewares: []string{"missing", "strip"},
+			want:        []string{"missing@docker::missing!", "strip@docker:stripprefix:enabled"},
+		},
+		{
+			name:        "disabled and erroneous middlewares are flagged",
+			middlewares: []string{"disabled", "broken"},
+			want:        []string{"disabled@docker:headers:disabled!", "broken@docker:headers:warning!"},
+		},
+		{
+			name:        "chains are followed by their middlewares",
+			middlewares: []string{"nested"},
+			want: []string{
+				"nested@docker:chain:enabled",
+				"secured@docker:chain:enabled",
+				"strip@docker:stripprefix:enabled",
+				"auth@file:forwardauth:enabled",
+			},
+			wantForwardAuth: []int{3},
+		},
+		{
+			name:            "chains qualify references with their own provider",
+			middlewares:     []string{"shared@file"},
+			want:            []string{"shared@file:chain:enabled", "auth@file:forwardauth:enabled"},
+			wantForwardAuth: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := newTestMiddlewareSnapshot(t)
+			chain, forwardAuth := snapshot.middlewareChain(ProtocolHTTP, "docker", tt.middlewares)
+
+			if got := describeChain(chain); !slices.Equal(got, tt.want) {
+				t.Errorf("chain = %v, want %v", got, tt.want)
+			}
+
+			var indices []int
+			for _, ref := range forwardAuth {
+				indices = append(indices, ref.index)
+				if ref.address != "http://auth" {
+					t.Errorf("forward auth address = %s, want http://auth", ref.address)
+				}
+			}
+			if !slices.Equal(indices, tt.wantForwardAuth) {
+				t.Errorf("forward auth indices = %v, want %v", indices, tt.wantForwardAuth)
+			}
+		})
+	}
+}
+
+func TestMiddlewareChainDepth(t *testing.T) {
+	snapshot := newTestMiddlewareSnapshot(t)
+	chain, _ := snapshot.middlewareChain(ProtocolHTTP, "docker", []string{"loop"})
+
+	if len(chain) != maxServiceDepth+1 {
+		t.Fatalf("chain length = %d, want %d", len(chain), maxServiceDepth+1)
+	}
+	last := chain[len(chain)-1]
+	if !last.Flagged || !slices.Contains(last.Errors, "maximum middleware nesting depth exceeded") {
+		t.Errorf("last middleware = %+v, want it flagged for exceeding the nesting depth", last)
+	}
+	for _, m := range chain[:len(chain)-1] {
+		if m.Flagged {
+			t.Errorf("middleware %+v flagged, only the last one should be", m)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package traefik
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// headerNextPage is set by Traefik on paginated responses if further pages
+// are available.
+const headerNextPage = "X-Next-Page"
+
+// ListOptions contain the filters supported by the list endpoints of the
+// Traefik API.
+// Empty filters are not sent to the API.
+type ListOptions struct {
+	Search   string // only list resources whose name contains the value
+	Status   string // only list resources with the status (enabled, disabled, warning)
+	Provider string // only list resources of the provider
+	PerPage  int    // number of resources requested per page
+}
+
+func (o ListOptions) query(page int) url.Values {
+	query := url.Values{}
+	if o.Search != "" {
+		query.Set("search", o.Search)
+	}
+	if o.Status != "" {
+		query.Set("status", o.Status)
+	}
+	if o.Provider != "" {
+		query.Set("provider", o.Provider)
+	}
+
+	perPage := o.PerPage
+	if perPage <= 0 {
+		perPage = config.Default.Viper().GetInt(config.ConfigurationKey_TraefikPageSize)
+	}
+	if perPage > 0 {
+		query.Set("per_page", strconv.Itoa(perPage))
+	}
+	query.Set("page", strconv.Itoa(page))
+	return query
+}
+
+// list requests all pages of a list endpoint.
+// Traefik indicates further pages using the X-Next-Page header, which is
+// followed until no further page is announced.
+func list[T any](ctx context.Context, c *Client, opts ListOptions, elements ...string) ([]T, error) {
+	var items []T
+	visited := make(map[int]struct{})
+	for page := 1; ; {
+		visited[page] = struct{}{}
+
+		var pageItems []T
+		header, err := c.getWithQuery(ctx, &pageItems, opts.query(page), elements...)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+
+		next, err := strconv.Atoi(header.Get(headerNextPage))
+		if err != nil || next <= page {
+			return items, nil
+		}
+		if _, seen := visited[next]; seen {
+			return items, nil
+		}
+		page = next
+	}
+}
+
+// Routers requests all routers of the supplied protocol matching the options.
+func (c *Client) Routers(ctx context.Context, protocol string, opts ListOptions) ([]v1.RouterListEntry, error) {
+	return list[v1.RouterListEntry](ctx, c, opts, protocol, "/routers")
+}
+
+// Services requests all services of the supplied protocol matching the
+// options.
+func (c *Client) Services(ctx context.Context, protocol string, opts ListOptions) ([]v1.Service, error) {
+	return list[v1.Service](ctx, c, opts, protocol, "/services")
+}
+
+// Middlewares requests all middlewares of the supplied protocol matching the
+// options.
+func (c *Client) Middlewares(ctx context.Context, protocol string, opts ListOptions) ([]v1.Middleware, error) {
+	return list[v1.Middleware](ctx, c, opts, protocol, "/middlewares")
+}
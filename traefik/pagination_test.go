@@ -0,0 +1,111 @@
+package traefik
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	v1 "microservice/types/v1"
+)
+
+// pagedServer serves the routers in pages and announces the next page as
+// configured in nextPages.
+func pagedServer(t *testing.T, pages [][]string, nextPages map[int]string) (*Client, *[]string) {
+	t.Helper()
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 || page > len(pages) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var routers []v1.RouterListEntry
+		for _, name := range pages[page-1] {
+			routers = append(routers, v1.RouterListEntry{Name: name})
+		}
+		if next, ok := nextPages[page]; ok {
+			w.Header().Set(headerNextPage, next)
+		}
+		_ = json.NewEncoder(w).Encode(routers)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(ClientOptions{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client, &queries
+}
+
+func TestListFollowsPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		pages     [][]string
+		nextPages map[int]string
+		want      []string
+		requests  int
+	}{
+		{"single page", [][]string{{"a", "b"}}, nil, []string{"a", "b"}, 1},
+		{"multiple pages", [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, map[int]string{1: "2", 2: "3"},
+			[]string{"a", "b", "c", "d", "e"}, 3},
+		{"next page pointing backwards", [][]string{{"a"}, {"b"}}, map[int]string{1: "2", 2: "1"},
+			[]string{"a", "b"}, 2},
+		{"invalid next page", [][]string{{"a"}, {"b"}}, map[int]string{1: "two"}, []string{"a"}, 1},
+		{"empty last page", [][]string{{"a"}, {}}, map[int]string{1: "2"}, []string{"a"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, queries := pagedServer(t, tt.pages, tt.nextPages)
+
+			routers, err := client.Routers(context.Background(), ProtocolHTTP, ListOptions{PerPage: 2})
+			if err != nil {
+				t.Fatalf("Routers() error = %v", err)
+			}
+
+			var names []string
+			for _, router := range routers {
+				names = append(names, router.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Routers() = %v, want %v", names, tt.want)
+			}
+			if len(*queries) != tt.requests {
+				t.Errorf("requests = %d, want %d", len(*queries), tt.requests)
+			}
+		})
+	}
+}
+
+func TestListSendsFilters(t *testing.T) {
+	client, queries := pagedServer(t, [][]string{{"a"}}, nil)
+
+	opts := ListOptions{Search: "api", Status: "enabled", Provider: "docker", PerPage: 10}
+	if _, err := client.Routers(context.Background(), ProtocolHTTP, opts); err != nil {
+		t.Fatalf("Routers() error = %v", err)
+	}
+
+	want := "page=1&per_page=10&provider=docker&search=api&status=enabled"
+	if len(*queries) != 1 || (*queries)[0] != want {
+		t.Errorf("queries = %v, want [%s]", *queries, want)
+	}
+}
+
+func TestListFailsOnUnexpectedStatus(t *testing.T) {
+	// the second page is announced but cannot be served
+	client, _ := pagedServer(t, [][]string{{"a"}}, map[int]string{1: "2"})
+
+	_, err := client.Routers(context.Background(), ProtocolHTTP, ListOptions{PerPage: 1})
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("Routers() error = %v, want %v", err, ErrUnexpectedStatus)
+	}
+}
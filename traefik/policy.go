@@ -0,0 +1,86 @@
+package traefik
+
+import (
+	"log/slog"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// The supported policies used to aggregate the states of a service's
+// upstreams into a single status.
+// Since [PolicyAll] requires every upstream to be up, it never reports a
+// service as "limited".
+const (
+	PolicyAll        = "all"        // every upstream needs to be up
+	PolicyAny        = "any"        // at least one upstream needs to be up (default)
+	PolicyMajority   = "majority"   // more than half of the upstreams need to be up
+	PolicyPercentage = "percentage" // a minimum percentage of upstreams needs to be up
+)
+
+const fullPercentage = 100
+
+// Policy describes how the states of a service's upstreams are aggregated.
+//
+// If all upstreams are up, the service is reported as "ok".
+// If the policy is still satisfied with some upstreams being down, the
+// service is reported as "limited".
+// Otherwise, the service is reported as "down".
+type Policy struct {
+	Mode       string
+	MinHealthy float64 // percentage used by [PolicyPercentage]
+}
+
+// PolicyFor returns the policy configured for the supplied path.
+// Settings not overridden for the path are taken from the global
+// configuration.
+func PolicyFor(path string) Policy {
+	c := config.Default.Viper()
+	p := Policy{
+		Mode:       c.GetString(config.ConfigurationKey_StatusPolicy),
+		MinHealthy: c.GetFloat64(config.ConfigurationKey_StatusMinHealthy),
+	}
+
+	settings := config.Default.PathSettings(path)
+	if settings.Policy != "" {
+		p.Mode = settings.Policy
+	}
+	if settings.MinHealthy != nil {
+		p.MinHealthy = *settings.MinHealthy
+	}
+
+	return p
+}
+
+// Evaluate aggregates the number of available upstreams into a status.
+func (p Policy) Evaluate(up, total int) string {
+	if total == 0 || up == 0 {
+		return v1.ServiceStatusDown
+	}
+
+	if up == total {
+		return v1.ServiceStatusOk
+	}
+
+	if p.satisfied(up, total) {
+		return v1.ServiceStatusIssues
+	}
+
+	return v1.ServiceStatusDown
+}
+
+func (p Policy) satisfied(up, total int) bool {
+	switch p.Mode {
+	case PolicyAll:
+		return up == total
+	case PolicyAny:
+		return up > 0
+	case PolicyMajority:
+		return up*2 > total
+	case PolicyPercentage:
+		return float64(up)*fullPercentage >= p.MinHealthy*float64(total)
+	default:
+		slog.Warn("unsupported status policy configured, falling back to 'any'", "policy", p.Mode)
+		return up > 0
+	}
+}
@@ -0,0 +1,35 @@
+package traefik
+
+import (
+	"testing"
+
+	v1 "microservice/types/v1"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		up, total int
+		want      string
+	}{
+		{"no upstreams", Policy{Mode: PolicyAny}, 0, 0, v1.ServiceStatusDown},
+		{"all up", Policy{Mode: PolicyAll}, 3, 3, v1.ServiceStatusOk},
+		{"all with one down", Policy{Mode: PolicyAll}, 2, 3, v1.ServiceStatusDown},
+		{"any with one up", Policy{Mode: PolicyAny}, 1, 3, v1.ServiceStatusIssues},
+		{"any with none up", Policy{Mode: PolicyAny}, 0, 3, v1.ServiceStatusDown},
+		{"majority satisfied", Policy{Mode: PolicyMajority}, 2, 3, v1.ServiceStatusIssues},
+		{"majority on a tie", Policy{Mode: PolicyMajority}, 1, 2, v1.ServiceStatusDown},
+		{"percentage satisfied", Policy{Mode: PolicyPercentage, MinHealthy: 50}, 2, 4, v1.ServiceStatusIssues},
+		{"percentage missed", Policy{Mode: PolicyPercentage, MinHealthy: 75}, 2, 4, v1.ServiceStatusDown},
+		{"unsupported falls back to any", Policy{Mode: "unknown"}, 1, 2, v1.ServiceStatusIssues},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Evaluate(tt.up, tt.total); got != tt.want {
+				t.Errorf("Evaluate(%d, %d) = %q, want %q", tt.up, tt.total, got, tt.want)
+			}
+		})
+	}
+}
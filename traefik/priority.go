@@ -0,0 +1,143 @@
+package traefik
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	config "microservice/internal/configuration"
+	v1 "microservice/types/v1"
+)
+
+// candidate is a router whose rule matches the request of a path.
+type candidate struct {
+	router v1.RouterListEntry
+
+	// certain is set if the rule definitely matches the request. Rules
+	// depending on request properties unknown to the monitor (e.g. headers)
+	// only possibly match it
+	certain bool
+}
+
+// effectivePriority returns the priority Traefik uses for the router.
+// If no priority has been set explicitly, Traefik uses the length of the
+// router's rule as priority.
+func effectivePriority(router v1.RouterListEntry) int {
+	if router.Priority != 0 {
+		return router.Priority
+	}
+	return len(router.Rule)
+}
+
+// compareCandidates orders the candidates of a single entrypoint the way
+// Traefik does, preferring routers which definitely match the request.
+// Routers sharing the same priority are ordered by their name to keep the
+// selection deterministic.
+func compareCandidates(a, b candidate) int {
+	if c := compareBool(a.certain, b.certain); c != 0 {
+		return c
+	}
+	if pa, pb := effectivePriority(a.router), effectivePriority(b.router); pa != pb {
+		return pb - pa
+	}
+	return strings.Compare(a.router.Name, b.router.Name)
+}
+
+// publicEntryPoints returns a function reporting if an entrypoint is one of
+// the configured public entrypoints.
+func publicEntryPoints() func(entryPoint string) bool {
+	entryPoints := configuredList(config.ConfigurationKey_TraefikPublicEntryPoints)
+	return func(entryPoint string) bool {
+		return slices.Contains(entryPoints, entryPoint)
+	}
+}
+
+// routerGroup contains the candidates ranked against each other by Traefik.
+type routerGroup struct {
+	entryPoint string
+	tls        bool
+	candidates []candidate
+}
+
+// winner returns the router handling the request on the entrypoint.
+func (g *routerGroup) winner() v1.RouterListEntry {
+	return g.candidates[0].router
+}
+
+// shadowed returns the routers of the entrypoint shadowed by the winner.
+func (g *routerGroup) shadowed() []v1.RouterListEntry {
+	var shadowed []v1.RouterListEntry
+	for _, c := range g.candidates[1:] {
+		shadowed = append(shadowed, c.router)
+	}
+	return shadowed
+}
+
+// rankRouters ranks the candidates the way Traefik does and returns the
+// routers competing on every entrypoint.
+// Traefik ranks the routers separately for every entrypoint and for tls and
+// non-tls requests, so routers of different groups never shadow each other.
+// The preferred group is returned first: groups with definite matches are
+// preferred, followed by groups of public entrypoints and tls groups. The
+// priorities of routers of different groups are never compared.
+func rankRouters(candidates []candidate, isPublic func(entryPoint string) bool) []*routerGroup {
+	type groupKey struct {
+		entryPoint string
+		tls        bool
+	}
+
+	index := make(map[groupKey]*routerGroup)
+	var groups []*routerGroup
+	for _, c := range candidates {
+		entryPoints := c.router.EntryPoints
+		if len(entryPoints) == 0 {
+			entryPoints = []string{""}
+		}
+		tls := c.router.TLS != nil
+		for _, entryPoint := range entryPoints {
+			key := groupKey{entryPoint: entryPoint, tls: tls}
+			g, ok := index[key]
+			if !ok {
+				g = &routerGroup{entryPoint: entryPoint, tls: tls}
+				index[key] = g
+				groups = append(groups, g)
+			}
+			g.candidates = append(g.candidates, c)
+		}
+	}
+
+	for _, g := range groups {
+		slices.SortStableFunc(g.candidates, compareCandidates)
+	}
+	slices.SortFunc(groups, func(a, b *routerGroup) int {
+		return compareGroups(a, b, isPublic)
+	})
+	return groups
+}
+
+// compareGroups orders the groups by the entrypoint they belong to, preferring
+// definite matches, public entrypoints and tls routers.
+func compareGroups(a, b *routerGroup, isPublic func(entryPoint string) bool) int {
+	if c := compareBool(a.candidates[0].certain, b.candidates[0].certain); c != 0 {
+		return c
+	}
+	if c := compareBool(isPublic(a.entryPoint), isPublic(b.entryPoint)); c != 0 {
+		return c
+	}
+	if c := compareBool(a.tls, b.tls); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.entryPoint, b.entryPoint)
+}
+
+// compareBool orders true before false.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,174 @@
+package traefik
+
+import (
+	"slices"
+	"testing"
+
+	v1 "microservice/types/v1"
+)
+
+func newRouter(name, rule string, entryPoints ...string) v1.RouterListEntry {
+	return v1.RouterListEntry{Name: name, Rule: rule, EntryPoints: entryPoints}
+}
+
+func withPriority(router v1.RouterListEntry, priority int) v1.RouterListEntry {
+	router.Priority = priority
+	return router
+}
+
+func withTLS(router v1.RouterListEntry) v1.RouterListEntry {
+	router.TLS = &v1.RouterTLS{}
+	return router
+}
+
+// isPublic reports the entrypoints of the default configuration as public.
+func isPublic(entryPoint string) bool {
+	return entryPoint == "web" || entryPoint == "websecure"
+}
+
+func TestRankRouters(t *testing.T) {
+	tests := []struct {
+		name         string
+		candidates   []candidate
+		wantWinner   string
+		wantShadowed []string
+
+		// wantEntryPoints contains the winner of every group as
+		// entrypoint=router, the preferred group first
+		wantEntryPoints []string
+	}{
+		{
+			name: "longer rule wins",
+			candidates: []candidate{
+				{router: newRouter("short", "PathPrefix(`/`)", "web"), certain: true},
+				{router: newRouter("long", "PathPrefix(`/api`)", "web"), certain: true},
+			},
+			wantWinner:   "long",
+			wantShadowed: []string{"short"},
+		},
+		{
+			name: "explicit priority wins",
+			candidates: []candidate{
+				{router: newRouter("long", "PathPrefix(`/api`)", "web"), certain: true},
+				{router: withPriority(newRouter("short", "PathPrefix(`/`)", "web"), 100), certain: true},
+			},
+			wantWinner:   "short",
+			wantShadowed: []string{"long"},
+		},
+		{
+			name: "equal priorities are ordered by name",
+			candidates: []candidate{
+				{router: newRouter("b", "Path(`/a`)", "web"), certain: true},
+				{router: newRouter("a", "Path(`/a`)", "web"), certain: true},
+			},
+			wantWinner:   "a",
+			wantShadowed: []string{"b"},
+		},
+		{
+			name: "tls and non-tls routers are ranked separately",
+			candidates: []candidate{
+				{router: newRouter("api-http", "Path(`/a`)", "web"), certain: true},
+				{router: withTLS(newRouter("api-https", "Path(`/a`)", "websecure")), certain: true},
+			},
+			wantWinner:      "api-https",
+			wantEntryPoints: []string{"websecure+tls=api-https", "web=api-http"},
+		},
+		{
+			name: "tls and non-tls routers on the same entrypoint",
+			candidates: []candidate{
+				{router: newRouter("a-http", "PathPrefix(`/api`)", "web"), certain: true},
+				{router: withTLS(newRouter("b-https", "PathPrefix(`/`)", "web")), certain: true},
+			},
+			wantWinner:      "b-https",
+			wantEntryPoints: []string{"web+tls=b-https", "web=a-http"},
+		},
+		{
+			name: "routers on different entrypoints do not shadow each other",
+			candidates: []candidate{
+				{router: newRouter("internal", "PathPrefix(`/api/x`)", "internal"), certain: true},
+				{router: newRouter("public", "PathPrefix(`/api`)", "web"), certain: true},
+				{router: newRouter("fallback", "PathPrefix(`/`)", "web"), certain: true},
+			},
+			wantWinner:      "public",
+			wantShadowed:    []string{"fallback"},
+			wantEntryPoints: []string{"web=public", "internal=internal"},
+		},
+		{
+			name: "priorities are not compared across entrypoints",
+			candidates: []candidate{
+				{router: withPriority(newRouter("internal", "PathPrefix(`/`)", "internal"), 1000), certain: true},
+				{router: newRouter("public", "PathPrefix(`/`)", "websecure"), certain: true},
+			},
+			wantWinner:      "public",
+			wantEntryPoints: []string{"websecure=public", "internal=internal"},
+		},
+		{
+			name: "non-public entrypoints are ordered by name",
+			candidates: []candidate{
+				{router: newRouter("b", "PathPrefix(`/api`)", "metrics"), certain: true},
+				{router: newRouter("a", "PathPrefix(`/`)", "admin"), certain: true},
+			},
+			wantWinner:      "a",
+			wantEntryPoints: []string{"admin=a", "metrics=b"},
+		},
+		{
+			name: "router shadowed on another entrypoint only",
+			candidates: []candidate{
+				{router: newRouter("api", "Path(`/a`)", "internal", "web"), certain: true},
+				{router: newRouter("fallback", "PathPrefix(`/`)", "internal"), certain: true},
+			},
+			wantWinner:      "api",
+			wantEntryPoints: []string{"web=api", "internal=fallback"},
+		},
+		{
+			name: "definite matches are preferred over unknown ones",
+			candidates: []candidate{
+				{router: newRouter("header", "PathPrefix(`/api`) && Header(`X-Beta`, `1`)", "web")},
+				{router: newRouter("api", "PathPrefix(`/api`)", "web"), certain: true},
+			},
+			wantWinner:   "api",
+			wantShadowed: []string{"header"},
+		},
+		{
+			name: "definite matches are preferred over tls routers",
+			candidates: []candidate{
+				{router: withTLS(newRouter("header", "Path(`/a`) && Header(`X`, `1`)", "websecure"))},
+				{router: newRouter("plain", "Path(`/a`)", "web"), certain: true},
+			},
+			wantWinner:      "plain",
+			wantEntryPoints: []string{"web=plain", "websecure+tls=header"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := rankRouters(tt.candidates, isPublic)
+			if winner := groups[0].winner(); winner.Name != tt.wantWinner {
+				t.Errorf("winner = %s, want %s", winner.Name, tt.wantWinner)
+			}
+
+			var names []string
+			for _, router := range groups[0].shadowed() {
+				names = append(names, router.Name)
+			}
+			if !slices.Equal(names, tt.wantShadowed) {
+				t.Errorf("shadowed = %v, want %v", names, tt.wantShadowed)
+			}
+
+			if tt.wantEntryPoints == nil {
+				return
+			}
+			var entryPoints []string
+			for _, g := range groups {
+				key := g.entryPoint
+				if g.tls {
+					key += "+tls"
+				}
+				entryPoints = append(entryPoints, key+"="+g.winner().Name)
+			}
+			if !slices.Equal(entryPoints, tt.wantEntryPoints) {
+				t.Errorf("entrypoints = %v, want %v", entryPoints, tt.wantEntryPoints)
+			}
+		})
+	}
+}
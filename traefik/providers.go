@@ -0,0 +1,60 @@
+package traefik
+
+import (
+	"slices"
+	"strings"
+
+	config "microservice/internal/configuration"
+)
+
+// providerInternal is the provider of the services Traefik creates itself
+// (e.g. api@internal or dashboard@internal).
+const providerInternal = "internal"
+
+// configuredProviders returns the providers whose routers are monitored.
+// An empty list allows routers of all providers.
+func configuredProviders() []string {
+	return configuredList(config.ConfigurationKey_TraefikProviders)
+}
+
+// configuredList returns the entries of a list configuration key.
+func configuredList(key string) []string {
+	var entries []string
+	for _, entry := range config.Default.Viper().GetStringSlice(key) {
+		// environment variables may contain a comma separated list
+		for _, value := range strings.Split(entry, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				entries = append(entries, value)
+			}
+		}
+	}
+	return entries
+}
+
+// allowedProviders returns a function reporting if routers of a provider are
+// monitored.
+// If no providers are configured, routers of all providers are monitored.
+func allowedProviders() func(provider string) bool {
+	providers := configuredProviders()
+	if len(providers) == 0 {
+		return func(string) bool { return true }
+	}
+
+	return func(provider string) bool {
+		return slices.ContainsFunc(providers, func(p string) bool {
+			return strings.EqualFold(p, provider)
+		})
+	}
+}
+
+// qualifiedName returns the name used to look up a service or middleware
+// referenced by a router.
+// References without a provider belong to the router's provider, while
+// references such as api@internal or whoami@file point to resources of
+// another provider and are used as they are.
+func qualifiedName(name, routerProvider string) string {
+	if strings.Contains(name, "@") {
+		return name
+	}
+	return name + "@" + routerProvider
+}
@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"strings"
+)
+
+// Request describes the parts of an incoming request which can be evaluated
+// against a rule.
+// Empty paths and hosts are treated as unknown and therefore never prevent a
+// rule from matching.
+// The SNI is only evaluated by the matchers of TCP rules, where an empty SNI
+// describes a connection without TLS.
+type Request struct {
+	Path string
+	Host string
+	SNI  string
+}
+
+// result is a three-valued logic result used during the evaluation of a rule.
+// Matchers which depend on request properties not known to the monitor (e.g.
+// headers or the client's ip address) evaluate to unknown, as a request could
+// satisfy them.
+// The ordering allows conjunctions to use the minimum and disjunctions to use
+// the maximum of their operands.
+type result int
+
+const (
+	noMatch result = iota
+	unknown
+	match
+)
+
+// Expr is a node of a parsed rule.
+type Expr interface {
+	eval(req Request) result
+	String() string
+}
+
+// And is satisfied if both operands are satisfied.
+type And struct {
+	Left, Right Expr
+}
+
+func (e And) eval(req Request) result {
+	return min(e.Left.eval(req), e.Right.eval(req))
+}
+
+func (e And) String() string {
+	return "(" + e.Left.String() + " && " + e.Right.String() + ")"
+}
+
+// Or is satisfied if at least one operand is satisfied.
+type Or struct {
+	Left, Right Expr
+}
+
+func (e Or) eval(req Request) result {
+	return max(e.Left.eval(req), e.Right.eval(req))
+}
+
+func (e Or) String() string {
+	return "(" + e.Left.String() + " || " + e.Right.String() + ")"
+}
+
+// Not negates the contained expression.
+type Not struct {
+	Expr Expr
+}
+
+func (e Not) eval(req Request) result {
+	switch e.Expr.eval(req) {
+	case match:
+		return noMatch
+	case noMatch:
+		return match
+	default:
+		return unknown
+	}
+}
+
+func (e Not) String() string {
+	return "!" + e.Expr.String()
+}
+
+// Matcher is a single matcher of a rule (e.g. PathPrefix(`/api`)).
+type Matcher struct {
+	Name string
+	Args []string
+
+	match func(req Request) result
+}
+
+func (e Matcher) eval(req Request) result {
+	if e.match == nil {
+		return unknown
+	}
+	return e.match(req)
+}
+
+func (e Matcher) String() string {
+	quoted := make([]string, len(e.Args))
+	for idx, arg := range e.Args {
+		quoted[idx] = "`" + arg + "`"
+	}
+	return e.Name + "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// collectHosts appends the literal hosts of the host matchers contained in the
+// expression.
+func collectHosts(e Expr, hosts []string) []string {
+	switch e := e.(type) {
+	case And:
+		return collectHosts(e.Right, collectHosts(e.Left, hosts))
+	case Or:
+		return collectHosts(e.Right, collectHosts(e.Left, hosts))
+	case Matcher:
+		if e.Name != "Host" && e.Name != "HostHeader" && e.Name != "HostSNI" {
+			return hosts
+		}
+		for _, host := range e.Args {
+			if host != "*" {
+				hosts = append(hosts, strings.ToLower(host))
+			}
+		}
+	}
+	return hosts
+}
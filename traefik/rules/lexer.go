@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of rule"
+	case tokenIdent:
+		return "matcher name"
+	case tokenString:
+		return "string"
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	case tokenComma:
+		return "','"
+	case tokenAnd:
+		return "'&&'"
+	case tokenOr:
+		return "'||'"
+	case tokenNot:
+		return "'!'"
+	default:
+		return "unknown token"
+	}
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lex splits the rule into the tokens used by the parser.
+func lex(rule string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(rule); {
+		r := rune(rule[pos])
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: pos})
+			pos++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, pos: pos})
+			pos++
+		case r == '!':
+			tokens = append(tokens, token{kind: tokenNot, pos: pos})
+			pos++
+		case strings.HasPrefix(rule[pos:], "&&"):
+			tokens = append(tokens, token{kind: tokenAnd, pos: pos})
+			pos += 2
+		case strings.HasPrefix(rule[pos:], "||"):
+			tokens = append(tokens, token{kind: tokenOr, pos: pos})
+			pos += 2
+		case r == '`':
+			end := strings.IndexByte(rule[pos+1:], '`')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string starting at position %d", pos)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: rule[pos+1 : pos+1+end], pos: pos})
+			pos += end + 2 //nolint:mnd
+		case r == '"':
+			value, length, err := lexQuoted(rule[pos:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string starting at position %d: %w", pos, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: pos})
+			pos += length
+		case isIdentRune(r):
+			start := pos
+			for pos < len(rule) && isIdentRune(rune(rule[pos])) {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: rule[start:pos], pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(rule)}), nil
+}
+
+// lexQuoted reads a double-quoted string from the start of s and returns the
+// unquoted value and the number of bytes consumed.
+func lexQuoted(s string) (string, int, error) {
+	escaped := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			value, err := strconv.Unquote(s[:i+1])
+			return value, i + 1, err
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,247 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type matchFunc func(req Request) result
+
+// buildMatcher creates the function evaluating a single matcher.
+// Matchers depending on request properties the monitor does not know (e.g.
+// Method or Header) return a nil function and are therefore evaluated as
+// unknown.
+func buildMatcher(name string, args []string, syntax string) (matchFunc, error) {
+	switch name {
+	case "Path":
+		return pathMatcher(args, syntax, false)
+	case "PathPrefix":
+		return pathMatcher(args, syntax, true)
+	case "PathRegexp":
+		return regexpMatcher(args, func(req Request) string { return req.Path })
+	case "Host", "HostHeader":
+		return hostMatcher(args), nil
+	case "HostRegexp":
+		if syntax == SyntaxV2 {
+			return templateHostMatcher(args)
+		}
+		return regexpMatcher(args, func(req Request) string { return strings.ToLower(req.Host) })
+	case "HostSNI":
+		return sniMatcher(args), nil
+	case "HostSNIRegexp":
+		return sniRegexpMatcher(args, syntax)
+	default:
+		return nil, nil //nolint:nilnil
+	}
+}
+
+func pathMatcher(args []string, syntax string, prefix bool) (matchFunc, error) {
+	if syntax == SyntaxV2 {
+		var expressions []*regexp.Regexp
+		for _, arg := range args {
+			if !strings.Contains(arg, "{") {
+				expressions = append(expressions, nil)
+				continue
+			}
+			expr, err := compileTemplate(arg, "[^/]+", !prefix)
+			if err != nil {
+				return nil, err
+			}
+			expressions = append(expressions, expr)
+		}
+
+		return func(req Request) result {
+			if req.Path == "" {
+				return unknown
+			}
+			for idx, arg := range args {
+				if expressions[idx] != nil {
+					if expressions[idx].MatchString(req.Path) {
+						return match
+					}
+					continue
+				}
+				if matchesPath(req.Path, arg, prefix) {
+					return match
+				}
+			}
+			return noMatch
+		}, nil
+	}
+
+	return func(req Request) result {
+		if req.Path == "" {
+			return unknown
+		}
+		for _, arg := range args {
+			if matchesPath(req.Path, arg, prefix) {
+				return match
+			}
+		}
+		return noMatch
+	}, nil
+}
+
+func matchesPath(path, expected string, prefix bool) bool {
+	if prefix {
+		return strings.HasPrefix(path, expected)
+	}
+	return path == expected
+}
+
+func hostMatcher(args []string) matchFunc {
+	return func(req Request) result {
+		if req.Host == "" {
+			return unknown
+		}
+		for _, host := range args {
+			if strings.EqualFold(req.Host, host) {
+				return match
+			}
+		}
+		return noMatch
+	}
+}
+
+// sniMatcher matches the server name sent by the client.
+// The wildcard `*` matches every connection, including those without TLS.
+func sniMatcher(args []string) matchFunc {
+	return func(req Request) result {
+		for _, sni := range args {
+			if sni == "*" || (req.SNI != "" && strings.EqualFold(req.SNI, sni)) {
+				return match
+			}
+		}
+		return noMatch
+	}
+}
+
+func sniRegexpMatcher(args []string, syntax string) (matchFunc, error) {
+	expressions := make([]*regexp.Regexp, 0, len(args))
+	for _, arg := range args {
+		var expr *regexp.Regexp
+		var err error
+		if syntax == SyntaxV2 {
+			expr, err = compileTemplate(strings.ToLower(arg), "[^.]+", true)
+		} else {
+			expr, err = regexp.Compile(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expr)
+	}
+
+	return func(req Request) result {
+		if req.SNI == "" {
+			return noMatch
+		}
+		for _, expr := range expressions {
+			if expr.MatchString(strings.ToLower(req.SNI)) {
+				return match
+			}
+		}
+		return noMatch
+	}, nil
+}
+
+func regexpMatcher(args []string, value func(req Request) string) (matchFunc, error) {
+	expressions := make([]*regexp.Regexp, 0, len(args))
+	for _, arg := range args {
+		expr, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expr)
+	}
+
+	return func(req Request) result {
+		v := value(req)
+		if v == "" {
+			return unknown
+		}
+		for _, expr := range expressions {
+			if expr.MatchString(v) {
+				return match
+			}
+		}
+		return noMatch
+	}, nil
+}
+
+func templateHostMatcher(args []string) (matchFunc, error) {
+	expressions := make([]*regexp.Regexp, 0, len(args))
+	for _, arg := range args {
+		expr, err := compileTemplate(strings.ToLower(arg), "[^.]+", true)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expr)
+	}
+
+	return func(req Request) result {
+		if req.Host == "" {
+			return unknown
+		}
+		for _, expr := range expressions {
+			if expr.MatchString(strings.ToLower(req.Host)) {
+				return match
+			}
+		}
+		return noMatch
+	}, nil
+}
+
+// compileTemplate converts a template using the v2 placeholder syntax
+// (e.g. /users/{id:[0-9]+}) into a regular expression.
+// Placeholders without an explicit pattern match the default pattern.
+func compileTemplate(template, defaultPattern string, anchorEnd bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for len(template) > 0 {
+		start := strings.IndexByte(template, '{')
+		if start == -1 {
+			b.WriteString(regexp.QuoteMeta(template))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(template[:start]))
+
+		end, err := placeholderEnd(template, start)
+		if err != nil {
+			return nil, err
+		}
+
+		placeholder := template[start+1 : end]
+		pattern := defaultPattern
+		if _, p, found := strings.Cut(placeholder, ":"); found {
+			pattern = p
+		}
+		b.WriteString("(?:" + pattern + ")")
+
+		template = template[end+1:]
+	}
+
+	if anchorEnd {
+		b.WriteString("$")
+	}
+
+	return regexp.Compile(b.String())
+}
+
+func placeholderEnd(template string, start int) (int, error) {
+	depth := 0
+	for idx := start; idx < len(template); idx++ {
+		switch template[idx] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return idx, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced braces in '%s'", template)
+}
@@ -0,0 +1,102 @@
+package rules
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		syntax string
+		req    Request
+		want   result
+	}{
+		// v3 syntax
+		{"path", "Path(`/api`)", SyntaxV3, Request{Path: "/api"}, match},
+		{"path mismatch", "Path(`/api`)", SyntaxV3, Request{Path: "/api/x"}, noMatch},
+		{"path prefix", "PathPrefix(`/api`)", SyntaxV3, Request{Path: "/api/x"}, match},
+		{"path regexp", "PathRegexp(`^/api/[0-9]+$`)", SyntaxV3, Request{Path: "/api/12"}, match},
+		{"v3 path without template", "Path(`/users/{id}`)", SyntaxV3, Request{Path: "/users/1"}, noMatch},
+		{"host is case insensitive", "Host(`Example.com`)", SyntaxV3, Request{Host: "example.COM"}, match},
+		{"host regexp", "HostRegexp(`^.+\\.example\\.com$`)", SyntaxV3, Request{Host: "a.example.com"}, match},
+		{"unknown path", "Path(`/api`)", SyntaxV3, Request{Host: "example.com"}, unknown},
+		{"unknown host", "Host(`example.com`)", SyntaxV3, Request{Path: "/api"}, unknown},
+
+		// v2 syntax
+		{"v2 path template", "Path(`/users/{id:[0-9]+}`)", SyntaxV2, Request{Path: "/users/12"}, match},
+		{"v2 path template mismatch", "Path(`/users/{id:[0-9]+}`)", SyntaxV2, Request{Path: "/users/ab"}, noMatch},
+		{"v2 template is anchored", "Path(`/users/{id}`)", SyntaxV2, Request{Path: "/users/1/x"}, noMatch},
+		{"v2 prefix template", "PathPrefix(`/users/{id}`)", SyntaxV2, Request{Path: "/users/1/x"}, match},
+		{"v2 nested braces", "Path(`/v/{v:[0-9]{2}}`)", SyntaxV2, Request{Path: "/v/12"}, match},
+		{"v2 host template", "HostRegexp(`{sub:[a-z]+}.example.com`)", SyntaxV2, Request{Host: "api.example.com"}, match},
+		{"v2 host template mismatch", "HostRegexp(`{sub}.example.com`)", SyntaxV2, Request{Host: "example.com"}, noMatch},
+		{"v2 multiple paths", "Path(`/a`, `/b`)", SyntaxV2, Request{Path: "/b"}, match},
+
+		// tcp matchers
+		{"sni", "HostSNI(`mqtt.example.com`)", SyntaxV3, Request{SNI: "mqtt.example.com"}, match},
+		{"sni without tls", "HostSNI(`mqtt.example.com`)", SyntaxV3, Request{}, noMatch},
+		{"sni catch-all", "HostSNI(`*`)", SyntaxV3, Request{}, match},
+		{"sni regexp", "HostSNIRegexp(`^.+\\.example\\.com$`)", SyntaxV3, Request{SNI: "a.example.com"}, match},
+		{"v2 sni template", "HostSNIRegexp(`{sub}.example.com`)", SyntaxV2, Request{SNI: "a.example.com"}, match},
+
+		// operators and unknown propagation
+		{"and", "Host(`x`) && Path(`/a`)", SyntaxV3, Request{Host: "x", Path: "/a"}, match},
+		{"and mismatch", "Host(`x`) && Path(`/a`)", SyntaxV3, Request{Host: "y", Path: "/a"}, noMatch},
+		{"or", "Path(`/a`) || Path(`/b`)", SyntaxV3, Request{Path: "/b"}, match},
+		{"not", "!Path(`/a`)", SyntaxV3, Request{Path: "/b"}, match},
+		{"not mismatch", "!Path(`/a`)", SyntaxV3, Request{Path: "/a"}, noMatch},
+		{"unsupported matcher", "Method(`GET`)", SyntaxV3, Request{Path: "/a"}, unknown},
+		{"unknown and match", "Path(`/a`) && Header(`X`, `1`)", SyntaxV3, Request{Path: "/a"}, unknown},
+		{"unknown and mismatch", "Path(`/a`) && Header(`X`, `1`)", SyntaxV3, Request{Path: "/b"}, noMatch},
+		{"unknown or match", "Path(`/a`) || Header(`X`, `1`)", SyntaxV3, Request{Path: "/a"}, match},
+		{"unknown or mismatch", "Path(`/a`) || Header(`X`, `1`)", SyntaxV3, Request{Path: "/b"}, unknown},
+		{"negated unknown", "!Query(`a`, `1`)", SyntaxV3, Request{Path: "/a"}, unknown},
+		{"precedence", "Path(`/b`) || Path(`/a`) && Host(`x`)", SyntaxV3, Request{Path: "/b", Host: "y"}, match},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule, tt.syntax)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := rule.Expr.eval(tt.req); got != tt.want {
+				t.Errorf("eval(%+v) = %d, want %d", tt.req, got, tt.want)
+			}
+			if got := rule.Matches(tt.req); got != (tt.want != noMatch) {
+				t.Errorf("Matches(%+v) = %t", tt.req, got)
+			}
+			if got := rule.MatchesCertainly(tt.req); got != (tt.want == match) {
+				t.Errorf("MatchesCertainly(%+v) = %t", tt.req, got)
+			}
+		})
+	}
+}
+
+func TestCompileTemplate(t *testing.T) {
+	tests := []struct {
+		template  string
+		anchorEnd bool
+		want      string
+	}{
+		{"/users", true, "^/users$"},
+		{"/users/{id}", true, "^/users/(?:[^/]+)$"},
+		{"/users/{id:[0-9]+}", false, "^/users/(?:[0-9]+)"},
+		{"/a.b/{v:[0-9]{2}}", true, `^/a\.b/(?:[0-9]{2})$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			expr, err := compileTemplate(tt.template, "[^/]+", tt.anchorEnd)
+			if err != nil {
+				t.Fatalf("compileTemplate() error = %v", err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("compileTemplate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compileTemplate("/users/{id", "[^/]+", true); err == nil {
+		t.Error("compileTemplate() succeeded for unbalanced braces")
+	}
+}
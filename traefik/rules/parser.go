@@ -0,0 +1,200 @@
+// Package rules implements a parser for the rule grammar used by Traefik
+// routers and allows evaluating whether a request would match a router.
+package rules
+
+import (
+	"fmt"
+)
+
+// The rule syntaxes supported by Traefik.
+const (
+	SyntaxV2 = "v2"
+	SyntaxV3 = "v3"
+)
+
+// Rule is a parsed Traefik router rule.
+type Rule struct {
+	Expr   Expr
+	Syntax string
+}
+
+// Parse parses the supplied rule using the given syntax.
+// If no syntax is supplied, the rule is parsed using [SyntaxV3].
+func Parse(rule, syntax string) (*Rule, error) {
+	if syntax == "" {
+		syntax = SyntaxV3
+	}
+	if syntax != SyntaxV2 && syntax != SyntaxV3 {
+		return nil, fmt.Errorf("unsupported rule syntax '%s'", syntax)
+	}
+
+	tokens, err := lex(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens, syntax: syntax}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", t.kind, t.pos)
+	}
+
+	return &Rule{Expr: expr, Syntax: syntax}, nil
+}
+
+// Matches reports whether a request could be matched by the rule.
+func (r *Rule) Matches(req Request) bool {
+	return r.Expr.eval(req) != noMatch
+}
+
+// MatchesCertainly reports whether a request definitely matches the rule.
+// Rules depending on request properties unknown to the monitor (e.g. headers)
+// only possibly match a request.
+func (r *Rule) MatchesCertainly(req Request) bool {
+	return r.Expr.eval(req) == match
+}
+
+// Hosts returns the literal hosts the rule matches using the Host, HostHeader
+// and HostSNI matchers.
+// Negated matchers, regular expressions and the catch-all HostSNI(`*`) are
+// not included.
+func (r *Rule) Hosts() []string {
+	return collectHosts(r.Expr, nil)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	syntax string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("expected %s but got %s at position %d", kind, t.kind, t.pos)
+	}
+	return t, nil
+}
+
+// parseOr parses a disjunction, which has the lowest precedence.
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokenIdent:
+		return p.parseMatcher(t)
+	default:
+		return nil, fmt.Errorf("unexpected %s at position %d", t.kind, t.pos)
+	}
+}
+
+func (p *parser) parseMatcher(name token) (Expr, error) {
+	if _, err := p.expect(tokenLParen); err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.expect(tokenString)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg.value)
+
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if _, err := p.expect(tokenRParen); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("matcher %s at position %d requires at least one argument", name.value, name.pos)
+	}
+
+	m := Matcher{Name: name.value, Args: args}
+	fn, err := buildMatcher(m.Name, m.Args, p.syntax)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matcher %s at position %d: %w", name.value, name.pos, err)
+	}
+	m.match = fn
+	return m, nil
+}
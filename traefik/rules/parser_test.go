@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"Path(`/a`)", "Path(`/a`)"},
+		{"Path(`/a`) || Path(`/b`) && Host(`x`)", "(Path(`/a`) || (Path(`/b`) && Host(`x`)))"},
+		{"Path(`/a`) && Path(`/b`) || Host(`x`)", "((Path(`/a`) && Path(`/b`)) || Host(`x`))"},
+		{"(Path(`/a`) || Path(`/b`)) && Host(`x`)", "((Path(`/a`) || Path(`/b`)) && Host(`x`))"},
+		{"!Path(`/a`) && Host(`x`)", "(!Path(`/a`) && Host(`x`))"},
+		{"!(Path(`/a`) && Host(`x`))", "!(Path(`/a`) && Host(`x`))"},
+		{"!!Path(`/a`)", "!!Path(`/a`)"},
+		{`Path("/a", "/b")`, "Path(`/a`, `/b`)"},
+		{`Host("a\"b")`, "Host(`a\"b`)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule, SyntaxV3)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := rule.Expr.String(); got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		syntax string
+	}{
+		{"empty rule", "", SyntaxV3},
+		{"unsupported syntax", "Path(`/a`)", "v1"},
+		{"unterminated backtick", "Path(`/a)", SyntaxV3},
+		{"unterminated quote", `Path("/a)`, SyntaxV3},
+		{"missing closing parenthesis", "Path(`/a`", SyntaxV3},
+		{"unbalanced group", "(Path(`/a`)", SyntaxV3},
+		{"trailing operator", "Path(`/a`) &&", SyntaxV3},
+		{"missing operator", "Path(`/a`) Path(`/b`)", SyntaxV3},
+		{"single ampersand", "Path(`/a`) & Path(`/b`)", SyntaxV3},
+		{"matcher without arguments", "Path()", SyntaxV3},
+		{"unquoted argument", "Path(/a)", SyntaxV3},
+		{"trailing comma", "Path(`/a`,)", SyntaxV3},
+		{"invalid regular expression", "PathRegexp(`(`)", SyntaxV3},
+		{"unbalanced template", "Path(`/users/{id`)", SyntaxV2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.rule, tt.syntax); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", tt.rule)
+			}
+		})
+	}
+}
+
+func TestParseDefaultSyntax(t *testing.T) {
+	rule, err := Parse("Path(`/a`)", "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if rule.Syntax != SyntaxV3 {
+		t.Errorf("Syntax = %q, want %q", rule.Syntax, SyntaxV3)
+	}
+}
+
+func TestRuleHosts(t *testing.T) {
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"Host(`A.example.com`) && PathPrefix(`/api`)", []string{"a.example.com"}},
+		{"Host(`a.example.com`, `b.example.com`) || HostHeader(`c.example.com`)",
+			[]string{"a.example.com", "b.example.com", "c.example.com"}},
+		{"!Host(`a.example.com`)", nil},
+		{"HostSNI(`*`)", nil},
+		{"HostSNI(`mqtt.example.com`)", []string{"mqtt.example.com"}},
+		{"HostRegexp(`.+\\.example\\.com`)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Parse(tt.rule, SyntaxV3)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := rule.Hosts(); !slices.Equal(got, tt.want) {
+				t.Errorf("Hosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,126 +1,335 @@
 package traefik
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
+	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
 	config "microservice/internal/configuration"
+	"microservice/traefik/rules"
 	v1 "microservice/types/v1"
 )
 
+// The statuses Traefik reports for routers, services and middlewares.
+// Resources with the status "warning" are still used by Traefik.
 const (
-	fPathPrefixRule = "PathPrefix(`%s`)"
-	fPathRule       = "Path(`%s`)"
+	resourceStatusEnabled  = "enabled"
+	resourceStatusDisabled = "disabled"
 )
 
-func ServiceStatus(paths ...string) (statuses []v1.ServiceStatus, err error) {
-	c := config.Default.Viper()
-	baseUrl := c.GetString(config.ConfigurationKey_TraefikAPIEndpoint)
-	routerOverview, err := url.JoinPath(baseUrl, "/api", "/http", "/routers")
-	if err != nil {
-		return nil, err
+// ServiceStatus computes the statuses of the supplied paths from a single
+// snapshot of the Traefik API.
+// The context is used for all requests made against the Traefik API.
+// The returned round trip is the time taken to fetch the snapshot, excluding
+// the probes of the forwardAuth middlewares.
+func (c *Client) ServiceStatus(
+	ctx context.Context, paths ...string,
+) (statuses []v1.ServiceStatus, roundTrip time.Duration, err error) {
+	targets := make(map[string][]string)
+	var protocols []string
+	for _, path := range paths {
+		protocol := ParseTarget(path).Protocol
+		if _, seen := targets[protocol]; !seen {
+			protocols = append(protocols, protocol)
+		}
+		targets[protocol] = append(targets[protocol], path)
 	}
-	res, err := http.Get(routerOverview) //nolint:gosec
+
+	start := time.Now()
+	snapshot, err := c.Snapshot(ctx, protocols...)
+	roundTrip = time.Since(start)
 	if err != nil {
-		return nil, err
+		return nil, roundTrip, err
 	}
 
-	var Routers []v1.RouterListEntry
-	err = json.NewDecoder(res.Body).Decode(&Routers)
-	if err != nil {
-		return nil, err
+	forwardAuth := make(map[string][]forwardAuthReference)
+	for _, protocol := range []string{ProtocolHTTP, ProtocolTCP, ProtocolUDP} {
+		if len(targets[protocol]) == 0 {
+			continue
+		}
+
+		protocolStatuses, references := snapshot.protocolStatus(protocol, targets[protocol])
+		statuses = append(statuses, protocolStatuses...)
+		for path, refs := range references {
+			forwardAuth[path] = refs
+		}
 	}
 
-	observedRouters := make(map[string]v1.RouterListEntry)
+	if config.Default.Viper().GetBool(config.ConfigurationKey_MiddlewaresProbeForwardAuth) {
+		applyForwardAuthProbes(ctx, statuses, forwardAuth)
+	}
 
-	for _, path := range paths {
-		var expectedRules []string
-		expectedRules = append(expectedRules, fmt.Sprintf(fPathPrefixRule, path))
-		expectedRules = append(expectedRules, fmt.Sprintf(fPathRule, path))
+	return statuses, roundTrip, nil
+}
 
-		for _, router := range Routers {
-			if router.Provider != "docker" {
+// applyForwardAuthProbes probes the forwardAuth middlewares used by the
+// routers of the statuses and degrades the statuses if the authentication
+// servers are unavailable.
+func applyForwardAuthProbes(
+	ctx context.Context, statuses []v1.ServiceStatus, references map[string][]forwardAuthReference,
+) {
+	var addresses []string
+	for _, refs := range references {
+		for _, ref := range refs {
+			addresses = append(addresses, ref.address)
+		}
+	}
+	if len(addresses) == 0 {
+		return
+	}
+
+	results := probeForwardAuth(ctx, addresses)
+	for idx := range statuses {
+		status := &statuses[idx]
+		for _, ref := range references[status.Path] {
+			result := results[ref.address]
+			if result.err == nil {
 				continue
 			}
 
-			for _, rule := range expectedRules {
-				if !strings.Contains(router.Rule, rule) {
-					continue
-				}
+			middleware := &status.Middlewares[ref.index]
+			middleware.Flagged = true
+			middleware.Errors = append(middleware.Errors, result.err.Error())
 
-				observedRouters[path] = router
+			if worse := v1.WorseStatus(status.Status, result.status); worse != status.Status {
+				status.Status = worse
+				status.Reason = v1.ReasonForwardAuthUnavailable
+				status.Message = fmt.Sprintf("forward auth server of middleware %s is unavailable", middleware.Name)
 			}
-
 		}
 	}
+}
 
-	for _, path := range paths {
-		if _, observed := observedRouters[path]; !observed {
-			statuses = append(statuses, v1.ServiceStatus{
-				Path:       path,
-				LastUpdate: time.Now(),
-				Status:     "down",
-			})
-		}
-	}
+// protocolStatus computes the statuses of the paths addressing routers of the
+// supplied protocol.
+// The references to forwardAuth middlewares used by the routers are returned
+// per path.
+func (s *Snapshot) protocolStatus(protocol string, paths []string) ([]v1.ServiceStatus, map[string][]forwardAuthReference) { //nolint:lll
+	var statuses []v1.ServiceStatus
+	forwardAuth := make(map[string][]forwardAuthReference)
 
-	for path, router := range observedRouters {
-		serviceName := fmt.Sprintf("%s@%s", router.Service, router.Provider)
+	Routers := s.Routers(protocol)
 
-		serviceDetailUrl, err := url.JoinPath(baseUrl, "/api/http/services/", serviceName)
-		if err != nil {
-			return nil, err
+	defaultSyntax := config.Default.Viper().GetString(config.ConfigurationKey_TraefikDefaultRuleSyntax)
+	isAllowed := allowedProviders()
+	parsedRules := make(map[int]*rules.Rule, len(Routers))
+	routerRules := make(map[string]*rules.Rule, len(Routers))
+	for idx, router := range Routers {
+		if !isAllowed(router.Provider) {
+			continue
 		}
 
-		detailResponse, err := http.Get(serviceDetailUrl) //nolint:gosec
-		if err != nil {
-			return nil, err
+		if protocol == ProtocolUDP {
+			// udp routers do not have any rules and only depend on their
+			// entrypoints
+			parsedRules[idx] = nil
+			continue
 		}
 
-		var service v1.Service
-		err = json.NewDecoder(detailResponse.Body).Decode(&service)
+		syntax := router.RuleSyntax
+		if syntax == "" {
+			syntax = defaultSyntax
+		}
+
+		rule, err := rules.Parse(router.Rule, syntax)
 		if err != nil {
-			return nil, err
+			slog.Warn("unable to parse router rule", "rule", router.Rule, "error", err)
+			continue
 		}
+		parsedRules[idx] = rule
+		routerRules[router.Name] = rule
+	}
 
-		var upstreamStatuses []bool
+	observedRouters := make(map[string]v1.RouterListEntry)
+	targets := make(map[string]Target)
+	shadowedRouters := make(map[string][]string)
+	entryPointRouters := make(map[string][]v1.EntryPointRouter)
+	isPublic := publicEntryPoints()
 
-		for _, upstream := range service.LoadBalancerConfig.Servers {
-			upstreamStatus, ok := service.ServerStatus[upstream.Url]
+	for _, path := range paths {
+		target := ParseTarget(path)
+		targets[path] = target
+		var candidates []candidate
+		for idx, router := range Routers {
+			rule, ok := parsedRules[idx]
 			if !ok {
-				upstreamStatuses = append(upstreamStatuses, false)
 				continue
 			}
 
-			if upstreamStatus != "UP" {
-				upstreamStatuses = append(upstreamStatuses, false)
+			if target.Router != "" {
+				if target.addressesRouter(router.Name) {
+					candidates = append(candidates, candidate{router: router, certain: true})
+				}
 				continue
 			}
 
-			upstreamStatuses = append(upstreamStatuses, true)
+			if target.EntryPoint != "" && !slices.Contains(router.EntryPoints, target.EntryPoint) {
+				continue
+			}
+
+			if rule == nil || rule.Matches(target.Request) {
+				certain := rule == nil || rule.MatchesCertainly(target.Request)
+				candidates = append(candidates, candidate{router: router, certain: certain})
+			}
 		}
 
+		if len(candidates) == 0 {
+			statuses = append(statuses, v1.ServiceStatus{
+				Path:       path,
+				LastUpdate: s.Taken(),
+				Status:     v1.ServiceStatusNotFound,
+				Reason:     v1.ReasonNoMatchingRouter,
+				Message:    "no router matches the path",
+			})
+			continue
+		}
+
+		groups := rankRouters(candidates, isPublic)
+		observedRouters[path] = groups[0].winner()
+		for _, router := range groups[0].shadowed() {
+			shadowedRouters[path] = append(shadowedRouters[path], router.Name)
+		}
+		if len(groups) > 1 {
+			entryPointRouters[path] = entryPointsOf(groups)
+		}
+	}
+
+	resolver := s.newServiceResolver(protocol)
+	for path, router := range observedRouters {
 		status := v1.ServiceStatus{
-			Path:       path,
-			LastUpdate: time.Now(),
-			Status:     v1.ServiceStatusDown,
+			Path:            path,
+			LastUpdate:      s.Taken(),
+			Router:          router.Name,
+			ShadowedRouters: shadowedRouters[path],
+			EntryPoints:     entryPointRouters[path],
+			Errors:          router.Err,
 		}
+		status.Middlewares, forwardAuth[path] = s.middlewareChain(protocol, router.Provider, router.Middlewares)
+		status.Certificates = certificateHosts(router, routerRules[router.Name], targets[path])
 
-		for _, upstreamAvailable := range upstreamStatuses {
-			if upstreamAvailable {
-				status.Status = v1.ServiceStatusOk
-			} else {
-				status.Status = v1.ServiceStatusDown
-				break
-			}
+		if router.Status == resourceStatusDisabled {
+			// traefik does not route any traffic using disabled routers, which
+			// is caused by errors in their configuration
+			status.Status = v1.ServiceStatusMisconfigured
+			status.Reason = v1.ReasonRouterDisabled
+			status.Message = fmt.Sprintf("router %s is disabled", router.Name)
+			statuses = append(statuses, status)
+			continue
+		}
+
+		pathResolver := resolver.withPolicy(PolicyFor(path))
+		serviceStatus, err := pathResolver.status(router.Service, router.Provider, 0)
+		status.Service = qualifiedName(router.Service, router.Provider)
+		_, status.Provider, _ = strings.Cut(status.Service, "@")
+		status.Upstreams = pathResolver.upstreams
+
+		switch {
+		case errors.Is(err, errServiceNotFound):
+			status.Status = v1.ServiceStatusMisconfigured
+			status.Reason = v1.ReasonServiceNotFound
+			status.Message = err.Error()
+			status.Errors = append(status.Errors, err.Error())
+		case err != nil:
+			slog.Warn("unable to resolve service of router", "router", router.Name, "error", err)
+			status.Status = v1.ServiceStatusDown
+			status.Reason = v1.ReasonServiceUnresolvable
+			status.Message = err.Error()
+			status.Errors = append(status.Errors, err.Error())
+		default:
+			status.Status = serviceStatus
+			describeServiceStatus(&status)
 		}
 
 		statuses = append(statuses, status)
 	}
 
-	return statuses, nil
+	return statuses, forwardAuth
+}
+
+// entryPointsOf returns the router handling the request on every entrypoint.
+func entryPointsOf(groups []*routerGroup) []v1.EntryPointRouter {
+	entryPoints := make([]v1.EntryPointRouter, 0, len(groups))
+	for _, g := range groups {
+		entryPoint := v1.EntryPointRouter{
+			EntryPoint: g.entryPoint,
+			TLS:        g.tls,
+			Router:     g.winner().Name,
+		}
+		for _, router := range g.shadowed() {
+			entryPoint.ShadowedRouters = append(entryPoint.ShadowedRouters, router.Name)
+		}
+		entryPoints = append(entryPoints, entryPoint)
+	}
+	return entryPoints
+}
+
+// describeServiceStatus sets the reason and message of a status computed from
+// the service of a router.
+func describeServiceStatus(status *v1.ServiceStatus) {
+	if status.Provider == providerInternal {
+		status.Reason = v1.ReasonInternalService
+		status.Message = "the service is provided by traefik itself"
+		return
+	}
+
+	available := 0
+	for _, upstream := range status.Upstreams {
+		if upstream.State != v1.UpstreamStateDown {
+			available++
+		}
+	}
+	status.Message = fmt.Sprintf("%d of %d upstreams available", available, len(status.Upstreams))
+
+	switch status.Status {
+	case v1.ServiceStatusOk:
+		status.Reason = v1.ReasonUpstreamsHealthy
+	case v1.ServiceStatusIssues:
+		status.Reason = v1.ReasonUpstreamsDegraded
+	default:
+		status.Reason = v1.ReasonUpstreamsUnavailable
+	}
+}
+
+// certificateHosts returns the hosts whose certificates are presented by the
+// tls router. If the target addresses a host, only its certificate is
+// relevant. Otherwise, the hosts of the rule and the domains of the tls
+// configuration are used.
+// The returned statuses only contain the hosts and are completed once the
+// certificates have been checked.
+func certificateHosts(router v1.RouterListEntry, rule *rules.Rule, target Target) []v1.CertificateStatus {
+	if router.TLS == nil {
+		return nil
+	}
+
+	var hosts []string
+	switch {
+	case target.Request.SNI != "":
+		hosts = append(hosts, target.Request.SNI)
+	case target.Request.Host != "":
+		hosts = append(hosts, target.Request.Host)
+	default:
+		if rule != nil {
+			hosts = rule.Hosts()
+		}
+		for _, domain := range router.TLS.Domains {
+			hosts = append(hosts, domain.Main)
+			hosts = append(hosts, domain.Sans...)
+		}
+	}
+
+	// wildcard domains cannot be connected to
+	hosts = slices.DeleteFunc(hosts, func(host string) bool {
+		return host == "" || strings.HasPrefix(host, "*")
+	})
+	slices.Sort(hosts)
+
+	var certificates []v1.CertificateStatus
+	for _, host := range slices.Compact(hosts) {
+		certificates = append(certificates, v1.CertificateStatus{Host: host})
+	}
+	return certificates
 }
@@ -0,0 +1,203 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "microservice/types/v1"
+)
+
+// maxServiceDepth limits how deep composite services are resolved to prevent
+// endless recursion on circular references.
+const maxServiceDepth = 10
+
+// defaultServiceWeight is the weight Traefik assigns to children of a weighted
+// service without an explicit weight.
+const defaultServiceWeight = 1
+
+var errServiceDepthExceeded = errors.New("maximum service nesting depth exceeded")
+
+var errServiceNotFound = errors.New("service does not exist")
+
+// serviceResolver resolves services and their children from a snapshot.
+// The servers of all load balancers used while resolving a service are
+// collected in upstreams.
+type serviceResolver struct {
+	snapshot  *Snapshot
+	protocol  string
+	policy    Policy
+	upstreams []v1.UpstreamStatus
+}
+
+func (s *Snapshot) newServiceResolver(protocol string) *serviceResolver {
+	return &serviceResolver{
+		snapshot: s,
+		protocol: protocol,
+	}
+}
+
+// withPolicy returns a copy of the resolver using the supplied policy without
+// any collected upstreams.
+func (r *serviceResolver) withPolicy(policy Policy) *serviceResolver {
+	return &serviceResolver{
+		snapshot: r.snapshot,
+		protocol: r.protocol,
+		policy:   policy,
+	}
+}
+
+func (r *serviceResolver) service(name string) (v1.Service, error) {
+	service, ok := r.snapshot.Service(r.protocol, name)
+	if !ok {
+		return v1.Service{}, fmt.Errorf("%w: %s", errServiceNotFound, name)
+	}
+	return service, nil
+}
+
+// status computes the status of the named service the way Traefik routes
+// traffic to it.
+// The provider is used to qualify references to child services which do not
+// specify a provider.
+func (r *serviceResolver) status(name, provider string, depth int) (string, error) {
+	if depth > maxServiceDepth {
+		return v1.ServiceStatusDown, fmt.Errorf("%w while resolving '%s'", errServiceDepthExceeded, name)
+	}
+
+	name = qualifiedName(name, provider)
+	service, err := r.service(name)
+	if err != nil {
+		return v1.ServiceStatusDown, err
+	}
+
+	if service.Provider == "" {
+		_, service.Provider, _ = strings.Cut(name, "@")
+	}
+
+	switch {
+	case service.Provider == providerInternal:
+		// internal services are served by traefik itself and are available
+		// as long as traefik answers
+		return v1.ServiceStatusOk, nil
+	case service.Weighted != nil:
+		return r.weightedStatus(service, depth)
+	case service.Mirroring != nil:
+		// mirrors only receive copies of the requests, the responses always
+		// originate from the main service
+		return r.status(service.Mirroring.Service, service.Provider, depth+1)
+	case service.Failover != nil:
+		return r.failoverStatus(service, depth)
+	default:
+		r.collectUpstreams(service)
+		return r.loadBalancerStatus(service), nil
+	}
+}
+
+func (r *serviceResolver) collectUpstreams(service v1.Service) {
+	for _, upstream := range service.LoadBalancerConfig.Servers {
+		key := upstream.Url
+		if key == "" {
+			key = upstream.Address
+		}
+
+		state, ok := service.ServerStatus[key]
+		if !ok {
+			state = v1.UpstreamStateDown
+			if r.protocol != ProtocolHTTP && len(service.ServerStatus) == 0 {
+				state = v1.UpstreamStateUnknown
+			}
+		}
+
+		r.upstreams = append(r.upstreams, v1.UpstreamStatus{
+			Url:     key,
+			Service: service.Name,
+			State:   state,
+		})
+	}
+}
+
+// loadBalancerStatus applies the policy to the states of the servers.
+// Traefik only reports server states for TCP services with a health check and
+// never for UDP services.
+// Without reported states, the servers of enabled services are assumed to be
+// available.
+func (r *serviceResolver) loadBalancerStatus(service v1.Service) string {
+	if r.protocol != ProtocolHTTP && len(service.ServerStatus) == 0 {
+		if service.Status != resourceStatusEnabled {
+			return v1.ServiceStatusDown
+		}
+		return r.policy.Evaluate(len(service.LoadBalancerConfig.Servers), len(service.LoadBalancerConfig.Servers))
+	}
+
+	var available int
+	for _, upstream := range service.LoadBalancerConfig.Servers {
+		key := upstream.Url
+		if key == "" {
+			key = upstream.Address
+		}
+		if service.ServerStatus[key] == v1.UpstreamStateUp {
+			available++
+		}
+	}
+
+	return r.policy.Evaluate(available, len(service.LoadBalancerConfig.Servers))
+}
+
+// weightedStatus applies the policy to the weights of the available children.
+// Children without any weight do not receive traffic and are ignored.
+func (r *serviceResolver) weightedStatus(service v1.Service, depth int) (string, error) {
+	var availableWeight, totalWeight int
+	degraded := false
+
+	for _, child := range service.Weighted.Services {
+		weight := defaultServiceWeight
+		if child.Weight != nil {
+			weight = *child.Weight
+		}
+		if weight <= 0 {
+			continue
+		}
+
+		status, err := r.status(child.Name, service.Provider, depth+1)
+		if err != nil {
+			return v1.ServiceStatusDown, err
+		}
+
+		totalWeight += weight
+		switch status {
+		case v1.ServiceStatusOk:
+			availableWeight += weight
+		case v1.ServiceStatusIssues:
+			availableWeight += weight
+			degraded = true
+		}
+	}
+
+	status := r.policy.Evaluate(availableWeight, totalWeight)
+	if status == v1.ServiceStatusOk && degraded {
+		return v1.ServiceStatusIssues, nil
+	}
+	return status, nil
+}
+
+// failoverStatus reports the status of the main service if it is available.
+// Otherwise, the requests are handled by the fallback service, which results
+// in a limited status at best.
+func (r *serviceResolver) failoverStatus(service v1.Service, depth int) (string, error) {
+	status, err := r.status(service.Failover.Service, service.Provider, depth+1)
+	if err != nil {
+		return v1.ServiceStatusDown, err
+	}
+	if status != v1.ServiceStatusDown {
+		return status, nil
+	}
+
+	fallbackStatus, err := r.status(service.Failover.Fallback, service.Provider, depth+1)
+	if err != nil {
+		return v1.ServiceStatusDown, err
+	}
+	if fallbackStatus != v1.ServiceStatusDown {
+		return v1.ServiceStatusIssues, nil
+	}
+	return v1.ServiceStatusDown, nil
+}
@@ -0,0 +1,271 @@
+package traefik
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	v1 "microservice/types/v1"
+)
+
+// testServices contains http services as returned by the rawdata endpoint.
+const testServices = `{
+	"up@docker": {
+		"loadBalancer": {"servers": [{"url": "http://up-1"}, {"url": "http://up-2"}]},
+		"serverStatus": {"http://up-1": "UP", "http://up-2": "UP"}
+	},
+	"half@docker": {
+		"loadBalancer": {"servers": [{"url": "http://half-1"}, {"url": "http://half-2"}]},
+		"serverStatus": {"http://half-1": "UP", "http://half-2": "DOWN"}
+	},
+	"down@docker": {
+		"loadBalancer": {"servers": [{"url": "http://down-1"}]},
+		"serverStatus": {"http://down-1": "DOWN"}
+	},
+	"shared@file": {
+		"loadBalancer": {"servers": [{"url": "http://shared-1"}]},
+		"serverStatus": {"http://shared-1": "UP"}
+	},
+	"weighted@docker": {
+		"weighted": {"services": [{"name": "up", "weight": 3}, {"name": "down", "weight": 1}]}
+	},
+	"weighted-zero@docker": {
+		"weighted": {"services": [{"name": "up", "weight": 1}, {"name": "down", "weight": 0}]}
+	},
+	"weighted-all-zero@docker": {
+		"weighted": {"services": [{"name": "up", "weight": 0}]}
+	},
+	"weighted-default@docker": {
+		"weighted": {"services": [{"name": "up"}, {"name": "down"}]}
+	},
+	"weighted-limited@docker": {
+		"weighted": {"services": [{"name": "up"}, {"name": "half"}]}
+	},
+	"weighted-missing@docker": {
+		"weighted": {"services": [{"name": "up"}, {"name": "missing"}]}
+	},
+	"failover-main@docker": {
+		"failover": {"service": "up", "fallback": "down"}
+	},
+	"failover-fallback@docker": {
+		"failover": {"service": "down", "fallback": "up"}
+	},
+	"failover-both-down@docker": {
+		"failover": {"service": "down", "fallback": "down"}
+	},
+	"mirroring@docker": {
+		"mirroring": {"service": "up", "mirrors": [{"name": "down", "percent": 100}]}
+	},
+	"mirroring-down@docker": {
+		"mirroring": {"service": "down", "mirrors": [{"name": "up", "percent": 100}]}
+	},
+	"cross-provider@docker": {
+		"weighted": {"services": [{"name": "shared@file"}, {"name": "up"}]}
+	},
+	"cycle-a@docker": {
+		"weighted": {"services": [{"name": "cycle-b"}]}
+	},
+	"cycle-b@docker": {
+		"failover": {"service": "cycle-a", "fallback": "up"}
+	},
+	"api@internal": {}
+}`
+
+func newTestResolver(t *testing.T) *serviceResolver {
+	t.Helper()
+
+	var services map[string]v1.Service
+	if err := json.Unmarshal([]byte(testServices), &services); err != nil {
+		t.Fatalf("unable to decode test services: %v", err)
+	}
+
+	snapshot := newSnapshot()
+	snapshot.addServices(ProtocolHTTP, services)
+	return snapshot.newServiceResolver(ProtocolHTTP).withPolicy(Policy{Mode: PolicyAny})
+}
+
+func TestServiceResolverStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       string
+		provider      string
+		want          string
+		wantErr       error
+		wantUpstreams []string
+	}{
+		{
+			name:          "load balancer",
+			service:       "up",
+			provider:      "docker",
+			want:          v1.ServiceStatusOk,
+			wantUpstreams: []string{"http://up-1", "http://up-2"},
+		},
+		{
+			name:          "load balancer with a server down",
+			service:       "half",
+			provider:      "docker",
+			want:          v1.ServiceStatusIssues,
+			wantUpstreams: []string{"http://half-1", "http://half-2"},
+		},
+		{
+			name:          "weights of available children are aggregated",
+			service:       "weighted",
+			provider:      "docker",
+			want:          v1.ServiceStatusIssues,
+			wantUpstreams: []string{"http://up-1", "http://up-2", "http://down-1"},
+		},
+		{
+			name:          "children without weight are ignored",
+			service:       "weighted-zero",
+			provider:      "docker",
+			want:          v1.ServiceStatusOk,
+			wantUpstreams: []string{"http://up-1", "http://up-2"},
+		},
+		{
+			name:     "service without any weight is down",
+			service:  "weighted-all-zero",
+			provider: "docker",
+			want:     v1.ServiceStatusDown,
+		},
+		{
+			name:          "children use the default weight",
+			service:       "weighted-default",
+			provider:      "docker",
+			want:          v1.ServiceStatusIssues,
+			wantUpstreams: []string{"http://up-1", "http://up-2", "http://down-1"},
+		},
+		{
+			name:          "limited children limit the weighted service",
+			service:       "weighted-limited",
+			provider:      "docker",
+			want:          v1.ServiceStatusIssues,
+			wantUpstreams: []string{"http://up-1", "http://up-2", "http://half-1", "http://half-2"},
+		},
+		{
+			name:     "missing child",
+			service:  "weighted-missing",
+			provider: "docker",
+			want:     v1.ServiceStatusDown,
+			wantErr:  errServiceNotFound,
+		},
+		{
+			name:          "failover uses the main service",
+			service:       "failover-main",
+			provider:      "docker",
+			want:          v1.ServiceStatusOk,
+			wantUpstreams: []string{"http://up-1", "http://up-2"},
+		},
+		{
+			name:          "failover to the fallback service",
+			service:       "failover-fallback",
+			provider:      "docker",
+			want:          v1.ServiceStatusIssues,
+			wantUpstreams: []string{"http://down-1", "http://up-1", "http://up-2"},
+		},
+		{
+			name:          "failover without available service",
+			service:       "failover-both-down",
+			provider:      "docker",
+			want:          v1.ServiceStatusDown,
+			wantUpstreams: []string{"http://down-1", "http://down-1"},
+		},
+		{
+			name:          "mirrors are ignored",
+			service:       "mirroring",
+			provider:      "docker",
+			want:          v1.ServiceStatusOk,
+			wantUpstreams: []string{"http://up-1", "http://up-2"},
+		},
+		{
+			name:          "available mirrors do not help a main service being down",
+			service:       "mirroring-down",
+			provider:      "docker",
+			want:          v1.ServiceStatusDown,
+			wantUpstreams: []string{"http://down-1"},
+		},
+		{
+			name:          "references across providers",
+			service:       "cross-provider",
+			provider:      "docker",
+			want:          v1.ServiceStatusOk,
+			wantUpstreams: []string{"http://shared-1", "http://up-1", "http://up-2"},
+		},
+		{
+			name:          "qualified reference of a router",
+			service:       "shared@file",
+			provider:      "docker",
+			want:          v1.ServiceStatusOk,
+			wantUpstreams: []string{"http://shared-1"},
+		},
+		{
+			name:     "unqualified reference uses the provider of the router",
+			service:  "shared",
+			provider: "docker",
+			want:     v1.ServiceStatusDown,
+			wantErr:  errServiceNotFound,
+		},
+		{
+			name:     "internal service",
+			service:  "api@internal",
+			provider: "docker",
+			want:     v1.ServiceStatusOk,
+		},
+		{
+			name:     "circular references are cut off",
+			service:  "cycle-a",
+			provider: "docker",
+			want:     v1.ServiceStatusDown,
+			wantErr:  errServiceDepthExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t)
+			got, err := r.status(tt.service, tt.provider, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("status() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("status() = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var upstreams []string
+			for _, upstream := range r.upstreams {
+				upstreams = append(upstreams, upstream.Url)
+			}
+			if !slices.Equal(upstreams, tt.wantUpstreams) {
+				t.Errorf("upstreams = %v, want %v", upstreams, tt.wantUpstreams)
+			}
+		})
+	}
+}
+
+func TestServiceResolverDepth(t *testing.T) {
+	services := make(map[string]v1.Service)
+	for depth := range maxServiceDepth + 1 {
+		service := v1.Service{Mirroring: &v1.MirroringService{Service: serviceName(depth + 1)}}
+		services[serviceName(depth)+"@docker"] = service
+	}
+	services[serviceName(maxServiceDepth+1)+"@docker"] = v1.Service{}
+
+	snapshot := newSnapshot()
+	snapshot.addServices(ProtocolHTTP, services)
+	r := snapshot.newServiceResolver(ProtocolHTTP).withPolicy(Policy{Mode: PolicyAny})
+
+	// the chain is one level too deep when starting at the root
+	if _, err := r.status(serviceName(0), "docker", 0); !errors.Is(err, errServiceDepthExceeded) {
+		t.Errorf("status() of the root error = %v, want %v", err, errServiceDepthExceeded)
+	}
+	if _, err := r.status(serviceName(1), "docker", 0); err != nil {
+		t.Errorf("status() of the first child error = %v, want nil", err)
+	}
+}
+
+func serviceName(depth int) string {
+	return "level-" + string(rune('a'+depth))
+}
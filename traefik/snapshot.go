@@ -0,0 +1,196 @@
+package traefik
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "microservice/types/v1"
+)
+
+// rawDataRetryInterval determines how long the client uses the per-resource
+// endpoints before trying the rawdata endpoint again after it was unavailable.
+const rawDataRetryInterval = 10 * time.Minute
+
+// Snapshot contains the routers, services and middlewares of a Traefik
+// instance at a single point in time.
+// All statuses are evaluated from a snapshot without further requests.
+type Snapshot struct {
+	routers     map[string][]v1.RouterListEntry     // routers by protocol
+	services    map[string]map[string]v1.Service    // services by protocol and qualified name
+	middlewares map[string]map[string]v1.Middleware // middlewares by protocol and qualified name
+	taken       time.Time
+}
+
+// rawDataState remembers when the rawdata endpoint was unavailable last.
+type rawDataState struct {
+	lock        sync.Mutex
+	unavailable time.Time
+}
+
+func (s *rawDataState) available() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return time.Since(s.unavailable) >= rawDataRetryInterval
+}
+
+func (s *rawDataState) markUnavailable() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.unavailable = time.Now()
+}
+
+// Snapshot fetches the current configuration from the Traefik API.
+// The configuration is read with a single request to /api/rawdata.
+// If the endpoint does not exist, the configuration is assembled from the
+// per-resource endpoints of the supplied protocols. All other errors (e.g.
+// failed authentication or an unavailable api) are returned as they are.
+func (c *Client) Snapshot(ctx context.Context, protocols ...string) (*Snapshot, error) {
+	if c.rawData.available() {
+		snapshot, err := c.rawDataSnapshot(ctx)
+		if err == nil {
+			return snapshot, nil
+		}
+
+		if !errors.Is(err, ErrEndpointNotFound) {
+			return nil, err
+		}
+
+		slog.Warn("traefik rawdata endpoint unavailable, using per-resource endpoints", "error", err)
+		c.rawData.markUnavailable()
+	}
+
+	return c.resourceSnapshot(ctx, protocols...)
+}
+
+func (c *Client) rawDataSnapshot(ctx context.Context) (*Snapshot, error) {
+	var data v1.RawData
+	if err := c.get(ctx, &data, "/rawdata"); err != nil {
+		return nil, err
+	}
+
+	snapshot := newSnapshot()
+	snapshot.addRouters(ProtocolHTTP, data.Routers)
+	snapshot.addRouters(ProtocolTCP, data.TCPRouters)
+	snapshot.addRouters(ProtocolUDP, data.UDPRouters)
+	snapshot.addServices(ProtocolHTTP, data.Services)
+	snapshot.addServices(ProtocolTCP, data.TCPServices)
+	snapshot.addServices(ProtocolUDP, data.UDPServices)
+	snapshot.addMiddlewares(ProtocolHTTP, data.Middlewares)
+	snapshot.addMiddlewares(ProtocolTCP, data.TCPMiddlewares)
+	return snapshot, nil
+}
+
+func (c *Client) resourceSnapshot(ctx context.Context, protocols ...string) (*Snapshot, error) {
+	// routers can be filtered directly by the api if only a single provider
+	// is monitored. services are always requested completely as routers may
+	// reference services of other providers
+	var routerOptions ListOptions
+	if providers := configuredProviders(); len(providers) == 1 {
+		routerOptions.Provider = providers[0]
+	}
+
+	snapshot := newSnapshot()
+	for _, protocol := range protocols {
+		routers, err := c.Routers(ctx, protocol, routerOptions)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.routers[protocol] = routers
+
+		services, err := c.Services(ctx, protocol, ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range services {
+			snapshot.services[protocol][service.Name] = service
+		}
+
+		if protocol == ProtocolUDP {
+			// udp routers do not support middlewares
+			continue
+		}
+
+		middlewares, err := c.Middlewares(ctx, protocol, ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, middleware := range middlewares {
+			snapshot.middlewares[protocol][middleware.Name] = middleware
+		}
+	}
+
+	return snapshot, nil
+}
+
+func newSnapshot() *Snapshot {
+	s := &Snapshot{
+		routers:     make(map[string][]v1.RouterListEntry),
+		services:    make(map[string]map[string]v1.Service),
+		middlewares: make(map[string]map[string]v1.Middleware),
+		taken:       time.Now(),
+	}
+	for _, protocol := range []string{ProtocolHTTP, ProtocolTCP, ProtocolUDP} {
+		s.services[protocol] = make(map[string]v1.Service)
+		s.middlewares[protocol] = make(map[string]v1.Middleware)
+	}
+	return s
+}
+
+// addRouters adds the routers returned by the rawdata endpoint, which only
+// contain their name and provider in the key.
+func (s *Snapshot) addRouters(protocol string, routers map[string]v1.RouterListEntry) {
+	for name, router := range routers {
+		router.Name = name
+		if router.Provider == "" {
+			_, router.Provider, _ = strings.Cut(name, "@")
+		}
+		s.routers[protocol] = append(s.routers[protocol], router)
+	}
+}
+
+func (s *Snapshot) addServices(protocol string, services map[string]v1.Service) {
+	for name, service := range services {
+		service.Name = name
+		if service.Provider == "" {
+			_, service.Provider, _ = strings.Cut(name, "@")
+		}
+		s.services[protocol][name] = service
+	}
+}
+
+func (s *Snapshot) addMiddlewares(protocol string, middlewares map[string]v1.Middleware) {
+	for name, middleware := range middlewares {
+		middleware.Name = name
+		if middleware.Provider == "" {
+			_, middleware.Provider, _ = strings.Cut(name, "@")
+		}
+		s.middlewares[protocol][name] = middleware
+	}
+}
+
+// Routers returns the routers of the supplied protocol.
+func (s *Snapshot) Routers(protocol string) []v1.RouterListEntry {
+	return s.routers[protocol]
+}
+
+// Service returns the service of the supplied protocol with the qualified name.
+func (s *Snapshot) Service(protocol, name string) (v1.Service, bool) {
+	service, ok := s.services[protocol][name]
+	return service, ok
+}
+
+// Middleware returns the middleware of the supplied protocol with the
+// qualified name.
+func (s *Snapshot) Middleware(protocol, name string) (v1.Middleware, bool) {
+	middleware, ok := s.middlewares[protocol][name]
+	return middleware, ok
+}
+
+// Taken returns the point in time the snapshot has been taken.
+func (s *Snapshot) Taken() time.Time {
+	return s.taken
+}
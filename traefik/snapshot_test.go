@@ -0,0 +1,192 @@
+package traefik
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	v1 "microservice/types/v1"
+)
+
+const testRawData = `{
+	"routers": {
+		"api@docker": {"rule": "PathPrefix(` + "`/api`" + `)", "service": "api", "status": "enabled"}
+	},
+	"services": {
+		"api@docker": {"loadBalancer": {"servers": [{"url": "http://api"}]}, "status": "enabled"}
+	},
+	"middlewares": {
+		"strip@docker": {"stripPrefix": {"prefixes": ["/api"]}, "status": "enabled", "usedBy": ["api@docker"]},
+		"auth@file": {"forwardAuth": {"address": "http://auth"}, "status": "enabled"}
+	},
+	"tcpRouters": {
+		"db@file": {"rule": "HostSNI(` + "`*`" + `)", "service": "db"}
+	}
+}`
+
+// snapshotServer serves the responses configured per request path and records
+// the requested paths.
+func snapshotServer(t *testing.T, responses map[string]string, rawDataStatus int) (*Client, *[]string) {
+	t.Helper()
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if r.URL.Path == "/api/rawdata" && rawDataStatus != http.StatusOK {
+			w.WriteHeader(rawDataStatus)
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(ClientOptions{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client, &requests
+}
+
+func TestSnapshotFromRawData(t *testing.T) {
+	client, requests := snapshotServer(t, map[string]string{"/api/rawdata": testRawData}, http.StatusOK)
+
+	snapshot, err := client.Snapshot(context.Background(), ProtocolHTTP, ProtocolTCP)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if want := []string{"/api/rawdata"}; !slices.Equal(*requests, want) {
+		t.Errorf("requests = %v, want %v", *requests, want)
+	}
+
+	routers := snapshot.Routers(ProtocolHTTP)
+	if len(routers) != 1 || routers[0].Name != "api@docker" || routers[0].Provider != "docker" {
+		t.Errorf("http routers = %+v, want api@docker of provider docker", routers)
+	}
+	if routers := snapshot.Routers(ProtocolTCP); len(routers) != 1 || routers[0].Provider != "file" {
+		t.Errorf("tcp routers = %+v, want db@file of provider file", routers)
+	}
+
+	service, ok := snapshot.Service(ProtocolHTTP, "api@docker")
+	if !ok || service.Name != "api@docker" || service.Provider != "docker" {
+		t.Errorf("Service(api@docker) = %+v, %t", service, ok)
+	}
+
+	middlewareTypes := map[string]string{"strip@docker": "stripprefix", "auth@file": "forwardauth"}
+	for name, want := range middlewareTypes {
+		middleware, ok := snapshot.Middleware(ProtocolHTTP, name)
+		if !ok {
+			t.Errorf("Middleware(%s) not found", name)
+			continue
+		}
+		if middleware.Type != want {
+			t.Errorf("Middleware(%s).Type = %q, want %q", name, middleware.Type, want)
+		}
+	}
+}
+
+func TestSnapshotFallback(t *testing.T) {
+	responses := map[string]string{
+		"/api/http/routers":     `[{"name": "api@docker", "provider": "docker", "service": "api"}]`,
+		"/api/http/services":    `[{"name": "api@docker", "provider": "docker"}]`,
+		"/api/http/middlewares": `[{"name": "strip@docker", "type": "stripprefix", "stripPrefix": {}}]`,
+	}
+
+	tests := []struct {
+		name          string
+		status        int
+		wantErr       error
+		wantRequests  []string
+		wantFallback  bool
+		wantAvailable bool
+	}{
+		{
+			name:   "missing rawdata endpoint",
+			status: http.StatusNotFound,
+			wantRequests: []string{
+				"/api/rawdata", "/api/http/routers", "/api/http/services", "/api/http/middlewares",
+			},
+			wantFallback: true,
+		},
+		{
+			name:          "failed authentication",
+			status:        http.StatusUnauthorized,
+			wantErr:       ErrUnexpectedStatus,
+			wantRequests:  []string{"/api/rawdata"},
+			wantAvailable: true,
+		},
+		{
+			name:          "forbidden",
+			status:        http.StatusForbidden,
+			wantErr:       ErrUnexpectedStatus,
+			wantRequests:  []string{"/api/rawdata"},
+			wantAvailable: true,
+		},
+		{
+			name:          "failing gateway",
+			status:        http.StatusBadGateway,
+			wantErr:       ErrUnexpectedStatus,
+			wantRequests:  []string{"/api/rawdata"},
+			wantAvailable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := snapshotServer(t, responses, tt.status)
+
+			snapshot, err := client.Snapshot(context.Background(), ProtocolHTTP)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Snapshot() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(*requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", *requests, tt.wantRequests)
+			}
+			if available := client.rawData.available(); available != tt.wantAvailable {
+				t.Errorf("rawdata available = %t, want %t", available, tt.wantAvailable)
+			}
+			if !tt.wantFallback {
+				return
+			}
+
+			if routers := snapshot.Routers(ProtocolHTTP); len(routers) != 1 {
+				t.Errorf("routers = %+v, want a single router", routers)
+			}
+			if _, ok := snapshot.Middleware(ProtocolHTTP, "strip@docker"); !ok {
+				t.Error("middleware strip@docker not found")
+			}
+		})
+	}
+}
+
+func TestMiddlewareType(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"reported type", `{"type": "basicauth", "basicAuth": {}}`, "basicauth"},
+		{"derived from the configuration", `{"status": "enabled", "headers": {"customRequestHeaders": {}}}`, "headers"},
+		{"metadata only", `{"status": "enabled", "usedBy": ["api@docker"], "error": ["invalid"]}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var middleware v1.Middleware
+			if err := middleware.UnmarshalJSON([]byte(tt.json)); err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			if middleware.Type != tt.want {
+				t.Errorf("Type = %q, want %q", middleware.Type, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package traefik
+
+import (
+	"strings"
+
+	"microservice/traefik/rules"
+)
+
+// The protocols of the routers which can be monitored.
+const (
+	ProtocolHTTP = "http"
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+)
+
+// Target describes what a subscribed path addresses in Traefik.
+//
+// Plain paths (e.g. /api/dwd or example.com/api/dwd) address HTTP routers.
+// TCP and UDP routers are addressed either by their entrypoint and, for TCP,
+// the server name (e.g. tcp://websecure/mqtt.example.com or udp://dns) or by
+// the name of the router (e.g. tcp:postgres@docker).
+type Target struct {
+	Protocol   string
+	Request    rules.Request
+	EntryPoint string
+	Router     string
+}
+
+// ParseTarget converts a subscribed path into a [Target].
+func ParseTarget(path string) Target {
+	for _, protocol := range []string{ProtocolTCP, ProtocolUDP} {
+		if rest, found := strings.CutPrefix(path, protocol+"://"); found {
+			entryPoint, sni, _ := strings.Cut(rest, "/")
+			return Target{
+				Protocol:   protocol,
+				EntryPoint: entryPoint,
+				Request:    rules.Request{SNI: sni},
+			}
+		}
+
+		if router, found := strings.CutPrefix(path, protocol+":"); found {
+			return Target{Protocol: protocol, Router: router}
+		}
+	}
+
+	return Target{Protocol: ProtocolHTTP, Request: requestFor(path)}
+}
+
+// addressesRouter reports if the target addresses the named router.
+// Router names may be supplied with or without their provider.
+func (t Target) addressesRouter(name string) bool {
+	if t.Router == "" {
+		return false
+	}
+	if strings.Contains(t.Router, "@") {
+		return name == t.Router
+	}
+	routerName, _, _ := strings.Cut(name, "@")
+	return routerName == t.Router
+}
+
+// requestFor converts a subscribed path into the request evaluated against
+// the router rules.
+// A path may be prefixed with a host (e.g. example.com/api) to additionally
+// evaluate host constraints of the routers.
+func requestFor(path string) rules.Request {
+	if strings.HasPrefix(path, "/") {
+		return rules.Request{Path: path}
+	}
+
+	host, p, found := strings.Cut(path, "/")
+	if !found {
+		return rules.Request{Host: host, Path: "/"}
+	}
+	return rules.Request{Host: host, Path: "/" + p}
+}
@@ -0,0 +1,26 @@
+package v1
+
+import "time"
+
+// CertificateStatus describes the certificate chain presented for a host
+// served by a tls router.
+type CertificateStatus struct {
+	Host      string     `json:"host"`
+	CheckedAt *time.Time `json:"checkedAt,omitempty"`
+
+	Subject string   `json:"subject,omitempty"`
+	Issuer  string   `json:"issuer,omitempty"`
+	SANs    []string `json:"sans,omitempty"`
+
+	// NotAfter contains the earliest expiry of all certificates in the chain
+	NotAfter        *time.Time `json:"notAfter,omitempty"`
+	DaysUntilExpiry int        `json:"daysUntilExpiry"`
+
+	// Covered is set if the host is covered by the subject alternative names
+	// of the certificate. Trusted is set if the chain could be verified using
+	// the system's certificate pool
+	Covered bool `json:"covered"`
+	Trusted bool `json:"trusted"`
+
+	Error string `json:"error,omitempty"`
+}
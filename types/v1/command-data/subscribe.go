@@ -8,6 +8,17 @@ import (
 type Subscribe struct {
 	Paths    []string          `json:"paths"          validate:"required,gt=0,dive,gt=0"`
 	Interval duration.Duration `json:"updateInterval"`
+
+	// Detailed requests the details about the service and its upstreams
+	Detailed bool `json:"detailed"`
+
+	// ExtendedStatuses requests statuses besides the legacy statuses "ok",
+	// "limited" and "down"
+	ExtendedStatuses bool `json:"extendedStatuses"`
+
+	// Snapshots requests the full statuses on every update instead of only
+	// the transitions after the initial statuses
+	Snapshots bool `json:"snapshots"`
 }
 
 func (s Subscribe) Validate() error {
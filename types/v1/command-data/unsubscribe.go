@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// Unsubscribe removes the paths from the subscribed paths.
+// If no paths are supplied, all paths are unsubscribed.
+type Unsubscribe struct {
+	Paths []string `json:"paths" validate:"dive,gt=0"`
+}
+
+func (u Unsubscribe) Validate() error {
+	v := validator.New()
+	return v.Struct(u)
+}
@@ -1,14 +1,20 @@
 package v1
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// ErrInvalidCommandID is returned if the id of a command is neither a string
+// nor an integer.
+var ErrInvalidCommandID = errors.New("the id of a command needs to be a string or an integer")
+
 type Command struct {
 	Command string          `json:"command" validate:"required,gt=0"`
-	ID      string          `json:"id"`
+	ID      CommandID       `json:"id"`
 	Data    json.RawMessage `json:"data"    validate:"omitempty,required"`
 }
 
@@ -16,3 +22,44 @@ func (c Command) Validate() error {
 	v := validator.New()
 	return v.Struct(c)
 }
+
+// CommandID is the id a client assigned to a command. Clients may use strings
+// or integers, therefore the id is kept as encoded json and echoed back the
+// way it has been sent.
+type CommandID string
+
+func (id *CommandID) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*id = ""
+	case string:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		*id = CommandID(encoded)
+	case json.Number:
+		if _, err := v.Int64(); err != nil {
+			return ErrInvalidCommandID
+		}
+		*id = CommandID(v.String())
+	default:
+		return ErrInvalidCommandID
+	}
+	return nil
+}
+
+func (id CommandID) MarshalJSON() ([]byte, error) {
+	if id == "" {
+		return []byte("null"), nil
+	}
+	return []byte(id), nil
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCommandID(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    CommandID
+		wantErr error
+	}{
+		{"string", `{"command": "subscribe", "id": "abc"}`, `"abc"`, nil},
+		{"integer", `{"command": "subscribe", "id": 42}`, "42", nil},
+		{"missing", `{"command": "subscribe"}`, "", nil},
+		{"null", `{"command": "subscribe", "id": null}`, "", nil},
+		{"fraction", `{"command": "subscribe", "id": 1.5}`, "", ErrInvalidCommandID},
+		{"object", `{"command": "subscribe", "id": {"a": 1}}`, "", ErrInvalidCommandID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var command Command
+			err := json.Unmarshal([]byte(tt.json), &command)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+			if command.ID != tt.want {
+				t.Errorf("ID = %s, want %s", command.ID, tt.want)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			encoded, err := json.Marshal(Envelope{Type: FrameTypeAck, RelatedTo: command.ID})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var echoed struct {
+				RelatedTo json.RawMessage `json:"relatedTo"`
+			}
+			if err := json.Unmarshal(encoded, &echoed); err != nil {
+				t.Fatalf("Unmarshal() of the envelope error = %v", err)
+			}
+			if string(echoed.RelatedTo) != string(tt.want) {
+				t.Errorf("relatedTo = %s, want %s", echoed.RelatedTo, tt.want)
+			}
+		})
+	}
+}
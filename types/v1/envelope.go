@@ -0,0 +1,22 @@
+package v1
+
+import "encoding/json"
+
+// The types of the frames sent to the clients.
+const (
+	FrameTypeAck       = "ack"
+	FrameTypeSnapshot  = "snapshot"
+	FrameTypeUpdate    = "update"
+	FrameTypeError     = "error"
+	FrameTypeHeartbeat = "heartbeat"
+)
+
+// Envelope wraps every frame sent to the clients, allowing them to tell the
+// frames apart.
+// The sequence number is increased for every frame sent on a connection.
+type Envelope struct {
+	Type      string          `json:"type"`
+	RelatedTo CommandID       `json:"relatedTo,omitempty"`
+	Seq       uint64          `json:"seq"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
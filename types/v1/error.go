@@ -1,7 +1,15 @@
 package v1
 
+// The machine-readable codes of the errors sent to the clients.
+const (
+	ErrorCodeInvalidJSON    = "invalid-json"    // the message is not a valid json encoded command
+	ErrorCodeInvalidCommand = "invalid-command" // the command misses required fields
+	ErrorCodeUnknownCommand = "unknown-command" // the command is not supported
+	ErrorCodeInvalidData    = "invalid-data"    // the data of the command is invalid
+)
+
 type CommandError struct {
-	IncomingMessageID string `json:"relatedTo,omitempty"`
-	Error             string `json:"error"`
-	IncomingData      any    `json:"receivedData,omitempty"`
+	Code         string `json:"code"`
+	Error        string `json:"error"`
+	IncomingData any    `json:"receivedData,omitempty"`
 }
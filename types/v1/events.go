@@ -0,0 +1,33 @@
+package v1
+
+import "time"
+
+// Transition describes the change of a path's status since the status
+// previously sent to the client.
+type Transition struct {
+	Path      string    `json:"path"`
+	Previous  string    `json:"previous"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Stale is set if the status is the last known status, since Traefik is
+	// currently unavailable. A transition is also sent if only the stale flag
+	// changed. Age contains the time since the stale status was computed
+	Stale bool   `json:"stale,omitempty"`
+	Age   string `json:"age,omitempty"`
+}
+
+// Heartbeat is sent periodically to confirm the liveness of the connection.
+type Heartbeat struct {
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Ack confirms the successful execution of a command.
+type Ack struct {
+	Command string `json:"command"`
+
+	// Paths contains the paths subscribed after executing the command
+	Paths []string `json:"paths"`
+}
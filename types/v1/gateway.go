@@ -1,17 +1,147 @@
 package v1
 
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// RouterListEntry is used for the routers of all protocols.
+// UDP routers do not have a rule or priority.
 type RouterListEntry struct {
-	Service  string `json:"service"  validate:"requried"`
-	Rule     string `json:"rule"     validate:"required"`
-	Provider string `json:"provider" validate:"required"`
+	Name        string   `json:"name"`
+	Service     string   `json:"service"     validate:"requried"`
+	Rule        string   `json:"rule"        validate:"required"`
+	RuleSyntax  string   `json:"ruleSyntax"`
+	Priority    int      `json:"priority"`
+	EntryPoints []string `json:"entryPoints"`
+	Provider    string   `json:"provider"    validate:"required"`
+	Middlewares []string `json:"middlewares"`
+	Status      string   `json:"status"`
+	Err         []string `json:"error"`
+
+	TLS *RouterTLS `json:"tls,omitempty"`
 }
 
+// RouterTLS contains the tls configuration of a router.
+// The domains are used to request certificates from the certificate resolver.
+type RouterTLS struct {
+	Options      string `json:"options,omitempty"`
+	CertResolver string `json:"certResolver,omitempty"`
+	Passthrough  bool   `json:"passthrough,omitempty"`
+	Domains      []struct {
+		Main string   `json:"main"`
+		Sans []string `json:"sans,omitempty"`
+	} `json:"domains,omitempty"`
+}
+
+// Service is used for the services of all protocols.
+// HTTP services identify their servers by url while TCP and UDP services use
+// the address of the server.
 type Service struct {
-	Name               string `json:"name"`
+	Name               string   `json:"name"`
+	Provider           string   `json:"provider"`
+	Status             string   `json:"status"`
+	Err                []string `json:"error"`
 	LoadBalancerConfig struct {
 		Servers []struct {
-			Url string `json:"url"`
+			Url     string `json:"url,omitempty"`
+			Address string `json:"address,omitempty"`
 		} `json:"servers"`
 	} `json:"loadBalancer"`
+	Weighted     *WeightedService  `json:"weighted,omitempty"`
+	Mirroring    *MirroringService `json:"mirroring,omitempty"`
+	Failover     *FailoverService  `json:"failover,omitempty"`
 	ServerStatus map[string]string `json:"serverStatus"`
 }
+
+// WeightedService distributes the requests between its child services
+// according to their weight.
+type WeightedService struct {
+	Services []struct {
+		Name   string `json:"name"`
+		Weight *int   `json:"weight,omitempty"`
+	} `json:"services"`
+}
+
+// MirroringService sends the requests to its main service and mirrors them
+// to the configured mirrors, whose responses are discarded.
+type MirroringService struct {
+	Service string `json:"service"`
+	Mirrors []struct {
+		Name    string `json:"name"`
+		Percent int    `json:"percent"`
+	} `json:"mirrors"`
+}
+
+// FailoverService sends the requests to its main service and switches to the
+// fallback service if the main service is unavailable.
+type FailoverService struct {
+	Service  string `json:"service"`
+	Fallback string `json:"fallback"`
+}
+
+// RawData contains the complete runtime configuration of a Traefik instance
+// as returned by the /api/rawdata endpoint.
+// The routers and services are keyed by their qualified name
+// (e.g. whoami@docker).
+type RawData struct {
+	Routers     map[string]RouterListEntry `json:"routers"`
+	Services    map[string]Service         `json:"services"`
+	Middlewares map[string]Middleware      `json:"middlewares"`
+	TCPRouters  map[string]RouterListEntry `json:"tcpRouters"`
+	TCPServices map[string]Service         `json:"tcpServices"`
+	UDPRouters  map[string]RouterListEntry `json:"udpRouters"`
+	UDPServices map[string]Service         `json:"udpServices"`
+
+	TCPMiddlewares map[string]Middleware `json:"tcpMiddlewares"`
+}
+
+// Middleware is used for the middlewares of HTTP and TCP routers.
+// Only the configuration of middleware types relevant to the monitor is
+// decoded.
+type Middleware struct {
+	Name        string   `json:"name"`
+	Provider    string   `json:"provider"`
+	Type        string   `json:"type"`
+	Status      string   `json:"status"`
+	Err         []string `json:"error"`
+	ForwardAuth *struct {
+		Address string `json:"address"`
+	} `json:"forwardAuth,omitempty"`
+	Chain *struct {
+		Middlewares []string `json:"middlewares"`
+	} `json:"chain,omitempty"`
+}
+
+// middlewareFields contains the fields of a middleware which do not contain
+// its configuration.
+var middlewareFields = []string{"name", "provider", "type", "status", "error", "usedBy"}
+
+// UnmarshalJSON decodes the middleware.
+// The rawdata endpoint does not report the type of the middlewares. It is
+// therefore derived from the key containing the configuration of the
+// middleware (e.g. stripPrefix), which Traefik lowercases for the type.
+func (m *Middleware) UnmarshalJSON(data []byte) error {
+	type middleware Middleware
+	if err := json.Unmarshal(data, (*middleware)(m)); err != nil {
+		return err
+	}
+	if m.Type != "" {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key, value := range fields {
+		if slices.Contains(middlewareFields, key) || !bytes.HasPrefix(value, []byte("{")) {
+			continue
+		}
+		m.Type = strings.ToLower(key)
+		break
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package v1
+
+// Latency contains the latency percentiles observed for a path.
+// Every source is only set if samples have been recorded for it.
+type Latency struct {
+	Gateway   *LatencyPercentiles `json:"gateway,omitempty"`   // round trip of the traefik api
+	Probe     *LatencyPercentiles `json:"probe,omitempty"`     // duration of the synthetic probes
+	Upstreams *LatencyPercentiles `json:"upstreams,omitempty"` // tcp connect time to the upstreams
+}
+
+// LatencyPercentiles summarizes the latest latency samples of a source in
+// milliseconds.
+type LatencyPercentiles struct {
+	Samples int   `json:"samples"`
+	P50Ms   int64 `json:"p50Ms"`
+	P95Ms   int64 `json:"p95Ms"`
+	P99Ms   int64 `json:"p99Ms"`
+}
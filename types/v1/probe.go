@@ -0,0 +1,15 @@
+package v1
+
+import "time"
+
+// ProbeResult contains the outcome of the latest synthetic probe of a path.
+type ProbeResult struct {
+	Status     string    `json:"status"`
+	CheckedAt  time.Time `json:"checkedAt"`
+	LatencyMs  int64     `json:"latencyMs"`
+	StatusCode int       `json:"statusCode,omitempty"`
+
+	// Errors contains the failed assertions and the errors encountered while
+	// sending the probe
+	Errors []string `json:"errors,omitempty"`
+}
@@ -2,14 +2,187 @@ package v1
 
 import "time"
 
+// The legacy statuses are understood by every client.
 const (
 	ServiceStatusOk     = "ok"
 	ServiceStatusDown   = "down"
 	ServiceStatusIssues = "limited"
 )
 
+// The extended statuses are only sent to clients requesting them.
+// For all other clients, they are mapped to the legacy statuses.
+const (
+	ServiceStatusMisconfigured = "misconfigured" // the router or its service is misconfigured
+	ServiceStatusUnknown       = "unknown"       // the monitor could not reach traefik
+	ServiceStatusNotFound      = "not-found"     // no router matches the path
+	ServiceStatusMaintenance   = "maintenance"   // the path is in a configured maintenance
+	ServiceStatusFlapping      = "flapping"      // the status changes too often
+)
+
+// The reason codes explain why a path has its status.
+const (
+	ReasonUpstreamsHealthy       = "upstreams-healthy"
+	ReasonUpstreamsDegraded      = "upstreams-degraded"
+	ReasonUpstreamsUnavailable   = "upstreams-unavailable"
+	ReasonInternalService        = "internal-service"
+	ReasonRouterDisabled         = "router-disabled"
+	ReasonServiceNotFound        = "service-not-found"
+	ReasonServiceUnresolvable    = "service-unresolvable"
+	ReasonNoMatchingRouter       = "no-matching-router"
+	ReasonGatewayUnreachable     = "gateway-unreachable"
+	ReasonMaintenance            = "maintenance"
+	ReasonForwardAuthUnavailable = "forward-auth-unavailable"
+	ReasonTransitionPending      = "transition-pending"
+	ReasonFlapping               = "flapping"
+	ReasonProbeFailed            = "probe-failed"
+	ReasonLatencyDegraded        = "latency-degraded"
+	ReasonCertificateExpiring    = "certificate-expiring"
+	ReasonCertificateExpired     = "certificate-expired"
+	ReasonCertificateMismatch    = "certificate-mismatch"
+	ReasonCertificateUnavailable = "certificate-unavailable"
+)
+
+// legacyStatuses maps the extended statuses to the legacy statuses.
+var legacyStatuses = map[string]string{
+	ServiceStatusMisconfigured: ServiceStatusDown,
+	ServiceStatusUnknown:       ServiceStatusDown,
+	ServiceStatusNotFound:      ServiceStatusDown,
+	ServiceStatusMaintenance:   ServiceStatusDown,
+	ServiceStatusFlapping:      ServiceStatusIssues,
+}
+
 type ServiceStatus struct {
 	Path       string    `json:"path"`
 	LastUpdate time.Time `json:"lastUpdate"`
 	Status     string    `json:"status"`
+
+	// Reason contains a machine-readable code explaining the status while
+	// Message contains a human-readable explanation
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Stale is set if Traefik is currently unavailable and the status is the
+	// last known status. Age contains the time since the status was computed
+	// as ISO 8601 duration
+	Stale bool   `json:"stale,omitempty"`
+	Age   string `json:"age,omitempty"`
+
+	// Router contains the name of the router handling requests to the path
+	Router string `json:"router,omitempty"`
+
+	// ShadowedRouters contains the names of the routers which also match the
+	// path, but are not used due to their lower priority
+	ShadowedRouters []string `json:"shadowedRouters,omitempty"`
+
+	// EntryPoints contains the router handling requests to the path on every
+	// entrypoint, if the path is served on several entrypoints. Traefik ranks
+	// the routers per entrypoint, so Router is the router of the preferred
+	// entrypoint
+	EntryPoints []EntryPointRouter `json:"entryPoints,omitempty"`
+
+	// Errors contains the errors Traefik reported for the router and the
+	// errors encountered while resolving its service
+	Errors []string `json:"errors,omitempty"`
+
+	// Middlewares contains the middleware chain of the router in the order
+	// the middlewares are applied to requests
+	Middlewares []MiddlewareStatus `json:"middlewares,omitempty"`
+
+	// Service and Provider identify the Traefik service used by the router.
+	// They are only sent to subscribers requesting detailed statuses
+	Service  string `json:"service,omitempty"`
+	Provider string `json:"provider,omitempty"`
+
+	// Upstreams contains the servers of the service. They are only sent to
+	// subscribers requesting detailed statuses
+	Upstreams []UpstreamStatus `json:"upstreams,omitempty"`
+
+	// Probe contains the result of the latest synthetic probe, if a probe is
+	// configured for the path
+	Probe *ProbeResult `json:"probe,omitempty"`
+
+	// Latency contains the percentiles of the latencies recorded for the path
+	Latency *Latency `json:"latency,omitempty"`
+
+	// Certificates contains the certificates presented for the hosts of the
+	// router, if the router uses tls
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+}
+
+// EntryPointRouter describes the router handling requests to a path on a
+// single entrypoint. TLS and non-TLS routers are ranked separately.
+type EntryPointRouter struct {
+	EntryPoint      string   `json:"entryPoint"`
+	TLS             bool     `json:"tls,omitempty"`
+	Router          string   `json:"router"`
+	ShadowedRouters []string `json:"shadowedRouters,omitempty"`
+}
+
+// The states Traefik reports for single upstream servers.
+// UpstreamStateUnknown is used for TCP and UDP servers without health check.
+const (
+	UpstreamStateUp      = "UP"
+	UpstreamStateDown    = "DOWN"
+	UpstreamStateUnknown = "UNKNOWN"
+)
+
+// UpstreamStatus describes a single server of a service.
+// For composite services, Service contains the child service the server
+// belongs to.
+type UpstreamStatus struct {
+	Url     string     `json:"url"`
+	Service string     `json:"service"`
+	State   string     `json:"state"`
+	Since   *time.Time `json:"since,omitempty"`
+
+	// ConnectMs contains the time needed to establish a tcp connection to the
+	// upstream during the latest evaluation
+	ConnectMs *int64 `json:"connectMs,omitempty"`
+}
+
+// Legacy returns a copy of the status which only uses the legacy statuses.
+func (s ServiceStatus) Legacy() ServiceStatus {
+	if legacy, ok := legacyStatuses[s.Status]; ok {
+		s.Status = legacy
+	}
+	return s
+}
+
+// Summary returns a copy of the status without the details which are only sent
+// to subscribers requesting detailed statuses.
+func (s ServiceStatus) Summary() ServiceStatus {
+	s.Service = ""
+	s.Provider = ""
+	s.Upstreams = nil
+	return s
+}
+
+// MiddlewareStatus describes a single middleware used by a router.
+// Middlewares which are disabled, in error or missing are flagged.
+type MiddlewareStatus struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type,omitempty"`
+	Status  string   `json:"status"`
+	Errors  []string `json:"errors,omitempty"`
+	Flagged bool     `json:"flagged,omitempty"`
+}
+
+// severities orders the statuses from the best to the worst.
+var severities = map[string]int{
+	ServiceStatusOk:            0,
+	ServiceStatusIssues:        1,
+	ServiceStatusFlapping:      2, //nolint:mnd
+	ServiceStatusMaintenance:   3, //nolint:mnd
+	ServiceStatusUnknown:       4, //nolint:mnd
+	ServiceStatusDown:          5, //nolint:mnd
+	ServiceStatusNotFound:      6, //nolint:mnd
+	ServiceStatusMisconfigured: 7, //nolint:mnd
+}
+
+// WorseStatus returns the worse of both statuses.
+func WorseStatus(a, b string) string {
+	if severities[b] > severities[a] {
+		return b
+	}
+	return a
 }